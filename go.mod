@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/CodyGuo/win v0.0.0-20170113125346-08e6b7208274
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/kardianos/service v1.2.1
 )
 
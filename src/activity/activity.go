@@ -0,0 +1,190 @@
+// Package activity measures system load so operations can wait for busy machines
+package activity
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A measurement averages the load since the previous one. If that is older than
+// MaxSampleAge, a fresh baseline is taken and the load is measured over SampleWindow,
+// so a download that started just before the check is not diluted by the idle hours
+// since the service started.
+const (
+	SampleWindow = 5 * time.Second
+	MaxSampleAge = 2 * time.Minute
+)
+
+// Load is the system load averaged over the recent past, see SampleWindow
+type Load struct {
+	CPUPercent float64 // Busy share of all CPUs, 0-100
+	DiskKBps   float64 // Bytes read and written per second, in KB
+	NetKBps    float64 // Bytes received and sent per second on non-loopback interfaces, in KB
+}
+
+// String returns the load in a human readable form
+func (l Load) String() string {
+	return fmt.Sprintf("cpu %.0f%%, disk %.0f KB/s, net %.0f KB/s", l.CPUPercent, l.DiskKBps, l.NetKBps)
+}
+
+// Monitor measures the system load
+type Monitor interface {
+	Load() (Load, error)
+}
+
+// Config is the "busy" section of the config file. A zero threshold is not checked.
+type Config struct {
+	CPUPercent         float64 `json:"cpu_percent,omitempty"`
+	DiskKBps           float64 `json:"disk_kb_per_sec,omitempty"`
+	NetKBps            float64 `json:"net_kb_per_sec,omitempty"`
+	MaxPostponeMinutes int     `json:"max_postpone_minutes,omitempty"` // Longest total postponement of one operation
+}
+
+// Enabled reports whether any threshold is configured
+func (c Config) Enabled() bool {
+	return c.CPUPercent > 0 || c.DiskKBps > 0 || c.NetKBps > 0
+}
+
+// Validate rejects thresholds the monitor of this platform cannot measure
+func (c Config) Validate() error {
+	if !measuresIO && (c.DiskKBps > 0 || c.NetKBps > 0) {
+		return fmt.Errorf("busy: disk_kb_per_sec and net_kb_per_sec are not measured on this platform")
+	}
+	return nil
+}
+
+// Exceeded returns the metrics of l that are above their threshold, e.g. "cpu, net"
+func (c Config) Exceeded(l Load) string {
+	var over []string
+	if c.CPUPercent > 0 && l.CPUPercent > c.CPUPercent {
+		over = append(over, "cpu")
+	}
+	if c.DiskKBps > 0 && l.DiskKBps > c.DiskKBps {
+		over = append(over, "disk")
+	}
+	if c.NetKBps > 0 && l.NetKBps > c.NetKBps {
+		over = append(over, "net")
+	}
+	return strings.Join(over, ", ")
+}
+
+// String returns the thresholds in a human readable form
+func (c Config) String() string {
+	var parts []string
+	if c.CPUPercent > 0 {
+		parts = append(parts, fmt.Sprintf("cpu > %.0f%%", c.CPUPercent))
+	}
+	if c.DiskKBps > 0 {
+		parts = append(parts, fmt.Sprintf("disk > %.0f KB/s", c.DiskKBps))
+	}
+	if c.NetKBps > 0 {
+		parts = append(parts, fmt.Sprintf("net > %.0f KB/s", c.NetKBps))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Verdict is the outcome of a postponement decision
+type Verdict string
+
+const (
+	Postponed Verdict = "postponed" // Busy, the operation waits
+	Proceed   Verdict = "proceed"   // Idle enough, the operation runs
+	LimitHit  Verdict = "limit"     // Still busy, but the maximum postponement is used up
+	NoData    Verdict = "no-data"   // The load could not be measured, the operation runs
+)
+
+// Decision records one postponement decision
+type Decision struct {
+	Time      time.Time
+	Mode      string
+	Reason    string // What triggered the operation, e.g. "schedule"
+	Load      Load
+	Exceeded  string
+	Postponed time.Duration // Total postponement so far
+	Verdict   Verdict
+}
+
+// maxDecisions is how many decisions are kept for status
+const maxDecisions = 10
+
+// Guard decides whether a due operation has to wait and keeps the recent decisions
+type Guard struct {
+	Monitor Monitor
+
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// NewGuard returns a Guard that measures the load with m
+func NewGuard(m Monitor) *Guard {
+	return &Guard{Monitor: m}
+}
+
+// Check measures the load and decides whether an operation that has already
+// waited for postponed should wait longer
+func (g *Guard) Check(now time.Time, cfg Config, mode, reason string, postponed time.Duration) Decision {
+	d := Decision{Time: now, Mode: mode, Reason: reason, Postponed: postponed}
+	load, err := g.Monitor.Load()
+	switch {
+	case err != nil:
+		d.Verdict = NoData
+		d.Exceeded = err.Error()
+	default:
+		d.Load = load
+		d.Exceeded = cfg.Exceeded(load)
+		switch {
+		case d.Exceeded == "":
+			d.Verdict = Proceed
+		case postponed >= time.Duration(cfg.MaxPostponeMinutes)*time.Minute:
+			d.Verdict = LimitHit
+		default:
+			d.Verdict = Postponed
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.decisions = append(g.decisions, d)
+	if len(g.decisions) > maxDecisions {
+		g.decisions = g.decisions[len(g.decisions)-maxDecisions:]
+	}
+	return d
+}
+
+// Decisions returns a copy of the recent decisions, oldest first
+func (g *Guard) Decisions() []Decision {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	decisions := make([]Decision, len(g.decisions))
+	copy(decisions, g.decisions)
+	return decisions
+}
+
+// Fake is a Monitor for tests; the load is set by hand
+type Fake struct {
+	mu   sync.Mutex
+	load Load
+	err  error
+}
+
+// Set changes the reported load
+func (f *Fake) Set(l Load) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.load = l
+}
+
+// Fail makes Load return err, nil clears it
+func (f *Fake) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *Fake) Load() (Load, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.load, f.err
+}
@@ -0,0 +1,73 @@
+//go:build windows
+// +build windows
+
+package activity
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetSystemTimes = kernel32.NewProc("GetSystemTimes")
+)
+
+// Disk and network throughput are not measured on Windows, Config.Validate rejects
+// thresholds for them
+const measuresIO = false
+
+// windowsMonitor measures CPU load with GetSystemTimes
+type windowsMonitor struct {
+	mu        sync.Mutex
+	prevAt    time.Time
+	prevIdle  uint64
+	prevTotal uint64
+	last      Load
+}
+
+// NewMonitor returns the load monitor for this platform
+func NewMonitor() Monitor {
+	return &windowsMonitor{}
+}
+
+func (m *windowsMonitor) Load() (Load, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 上次测量太久以前时重新建立基准，只统计最近一段时间的负载
+	if m.prevAt.IsZero() || time.Since(m.prevAt) > MaxSampleAge {
+		idle, total, err := systemTimes()
+		if err != nil {
+			return Load{}, err
+		}
+		m.prevAt, m.prevIdle, m.prevTotal = time.Now(), idle, total
+		time.Sleep(SampleWindow)
+	}
+
+	idle, total, err := systemTimes()
+	if err != nil {
+		return Load{}, err
+	}
+	if time.Since(m.prevAt) < time.Second || total <= m.prevTotal {
+		return m.last, nil
+	}
+	m.last = Load{CPUPercent: float64((total-m.prevTotal)-(idle-m.prevIdle)) * 100 / float64(total-m.prevTotal)}
+	m.prevAt, m.prevIdle, m.prevTotal = time.Now(), idle, total
+	return m.last, nil
+}
+
+// systemTimes returns the idle and total CPU time in 100ns units; kernel time includes idle time
+func systemTimes() (uint64, uint64, error) {
+	var idle, kernel, user syscall.Filetime
+	ret, _, err := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)), uintptr(unsafe.Pointer(&kernel)), uintptr(unsafe.Pointer(&user)))
+	if ret == 0 {
+		return 0, 0, fmt.Errorf("GetSystemTimes: %v", err)
+	}
+	ft := func(t syscall.Filetime) uint64 { return uint64(t.HighDateTime)<<32 | uint64(t.LowDateTime) }
+	return ft(idle), ft(kernel) + ft(user), nil
+}
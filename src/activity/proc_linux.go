@@ -0,0 +1,205 @@
+//go:build linux
+// +build linux
+
+package activity
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The disk and network throughput is read from /proc
+const measuresIO = true
+
+// counters are the cumulative values read from /proc
+type counters struct {
+	at       time.Time
+	cpuBusy  uint64 // jiffies
+	cpuTotal uint64
+	disk     uint64 // bytes
+	net      uint64 // bytes
+}
+
+// procMonitor reads /proc/stat, /proc/diskstats and /proc/net/dev below root
+// and reports the rates since the previous call
+type procMonitor struct {
+	root  string
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	mu   sync.Mutex
+	prev counters
+	last Load
+}
+
+// NewMonitor returns the load monitor for this platform
+func NewMonitor() Monitor {
+	return NewProcMonitor("/")
+}
+
+// NewProcMonitor returns a Monitor for the /proc and /sys trees below root, e.g. a fake tree in tests
+func NewProcMonitor(root string) Monitor {
+	return &procMonitor{root: root, now: time.Now, sleep: time.Sleep}
+}
+
+func (m *procMonitor) Load() (Load, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 上次测量太久以前时重新建立基准，只统计最近一段时间的负载
+	if m.prev.at.IsZero() || m.now().Sub(m.prev.at) > MaxSampleAge {
+		base, err := m.read()
+		if err != nil {
+			return Load{}, err
+		}
+		m.prev = base
+		m.sleep(SampleWindow)
+	}
+
+	cur, err := m.read()
+	if err != nil {
+		return Load{}, err
+	}
+	// 间隔太短时沿用上次的结果
+	elapsed := cur.at.Sub(m.prev.at).Seconds()
+	if elapsed < 1 {
+		return m.last, nil
+	}
+
+	var load Load
+	if total := cur.cpuTotal - m.prev.cpuTotal; total > 0 && cur.cpuTotal >= m.prev.cpuTotal {
+		load.CPUPercent = float64(cur.cpuBusy-m.prev.cpuBusy) * 100 / float64(total)
+	}
+	// 计数器回绕或设备消失时不计算
+	if cur.disk >= m.prev.disk {
+		load.DiskKBps = float64(cur.disk-m.prev.disk) / 1024 / elapsed
+	}
+	if cur.net >= m.prev.net {
+		load.NetKBps = float64(cur.net-m.prev.net) / 1024 / elapsed
+	}
+	m.prev = cur
+	m.last = load
+	return load, nil
+}
+
+// read collects the current counters
+func (m *procMonitor) read() (counters, error) {
+	c := counters{at: m.now()}
+	var err error
+	if c.cpuBusy, c.cpuTotal, err = m.readStat(); err != nil {
+		return c, err
+	}
+	if c.disk, err = m.readDiskstats(); err != nil {
+		return c, err
+	}
+	if c.net, err = m.readNetDev(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// readStat returns the busy and total jiffies of the "cpu" line of /proc/stat
+func (m *procMonitor) readStat() (uint64, uint64, error) {
+	lines, err := m.lines("proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var total, idle uint64
+		for i, f := range fields[1:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return 0, 0, fmt.Errorf("/proc/stat: %v", err)
+			}
+			// guest and guest_nice are already counted in user and nice
+			if i >= 8 {
+				break
+			}
+			total += v
+			// idle and iowait
+			if i == 3 || i == 4 {
+				idle += v
+			}
+		}
+		return total - idle, total, nil
+	}
+	return 0, 0, fmt.Errorf("/proc/stat: no cpu line")
+}
+
+// readDiskstats returns the bytes read and written by whole disks.
+// Partitions, loop, RAM and device-mapper devices are skipped so nothing is counted twice.
+func (m *procMonitor) readDiskstats() (uint64, error) {
+	lines, err := m.lines("proc/diskstats")
+	if err != nil {
+		return 0, err
+	}
+	var sectors uint64
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") ||
+			strings.HasPrefix(name, "dm-") || strings.HasPrefix(name, "zram") || strings.HasPrefix(name, "md") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(m.root, "sys/block", name)); err != nil {
+			continue
+		}
+		read, _ := strconv.ParseUint(fields[5], 10, 64)
+		written, _ := strconv.ParseUint(fields[9], 10, 64)
+		sectors += read + written
+	}
+	// diskstats 中的扇区固定为512字节
+	return sectors * 512, nil
+}
+
+// readNetDev returns the bytes received and sent on all interfaces except loopback
+func (m *procMonitor) readNetDev() (uint64, error) {
+	lines, err := m.lines("proc/net/dev")
+	if err != nil {
+		return 0, err
+	}
+	var bytes uint64
+	for _, line := range lines {
+		colon := strings.Index(line, ":")
+		if colon < 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:colon])
+		fields := strings.Fields(line[colon+1:])
+		if name == "lo" || len(fields) < 9 {
+			continue
+		}
+		rx, _ := strconv.ParseUint(fields[0], 10, 64)
+		tx, _ := strconv.ParseUint(fields[8], 10, 64)
+		bytes += rx + tx
+	}
+	return bytes, nil
+}
+
+// lines reads a file below root line by line
+func (m *procMonitor) lines(name string) ([]string, error) {
+	f, err := os.Open(filepath.Join(m.root, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
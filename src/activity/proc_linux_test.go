@@ -0,0 +1,113 @@
+//go:build linux
+// +build linux
+
+package activity
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeProc is a /proc and /sys tree with counters that grow while the monitor sleeps
+type fakeProc struct {
+	t    *testing.T
+	root string
+	now  time.Time
+
+	busy, idle, sectors, bytes uint64
+}
+
+func (p *fakeProc) write() {
+	p.t.Helper()
+	files := map[string]string{
+		"proc/stat":      fmt.Sprintf("cpu  %d 0 0 %d 0 0 0 0 0 0\ncpu0 1 0 0 1 0 0 0 0 0 0\n", p.busy, p.idle),
+		"proc/diskstats": fmt.Sprintf("   8       0 sda 1 0 %d 0 1 0 0 0 0 0 0\n   8       1 sda1 1 0 999999 0 1 0 0 0 0 0 0\n", p.sectors),
+		"proc/net/dev": fmt.Sprintf("Inter-|   Receive\n face |bytes\n    lo: 999999 0 0 0 0 0 0 0 999999 0 0 0 0 0 0 0\n"+
+			"  eth0: %d 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", p.bytes),
+	}
+	for name, content := range files {
+		path := filepath.Join(p.root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			p.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			p.t.Fatal(err)
+		}
+	}
+}
+
+// busyFor advances the clock by d with the CPU fully busy and the given disk and network rates in KB/s
+func (p *fakeProc) busyFor(d time.Duration, diskKBps, netKBps float64) {
+	p.now = p.now.Add(d)
+	p.busy += uint64(d.Seconds() * 100)
+	p.sectors += uint64(diskKBps * 1024 / 512 * d.Seconds())
+	p.bytes += uint64(netKBps * 1024 * d.Seconds())
+	p.write()
+}
+
+// idleFor advances the clock by d without any load
+func (p *fakeProc) idleFor(d time.Duration) {
+	p.now = p.now.Add(d)
+	p.idle += uint64(d.Seconds() * 100)
+	p.write()
+}
+
+func newFakeProc(t *testing.T) (*fakeProc, *procMonitor) {
+	p := &fakeProc{t: t, root: t.TempDir(), now: time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)}
+	if err := os.MkdirAll(filepath.Join(p.root, "sys/block/sda"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	p.write()
+	m := NewProcMonitor(p.root).(*procMonitor)
+	m.now = func() time.Time { return p.now }
+	return p, m
+}
+
+func TestProcMonitorFreshBaseline(t *testing.T) {
+	p, m := newFakeProc(t)
+	var slept []time.Duration
+	m.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		p.busyFor(d, 2048, 500)
+	}
+
+	// 服务启动后空闲了四个小时，下载刚刚开始：只统计检查前的采样时间
+	p.idleFor(4 * time.Hour)
+	load, err := m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slept) != 1 || slept[0] != SampleWindow {
+		t.Errorf("slept %v, want one SampleWindow", slept)
+	}
+	if math.Abs(load.CPUPercent-100) > 0.1 || math.Abs(load.DiskKBps-2048) > 1 || math.Abs(load.NetKBps-500) > 1 {
+		t.Errorf("Load() = %v, want cpu 100%%, disk 2048 KB/s, net 500 KB/s", load)
+	}
+
+	// 一分钟后再次检查时使用上次测量以来的平均值，不再采样
+	p.busyFor(30*time.Second, 0, 0)
+	p.idleFor(30 * time.Second)
+	load, err = m.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slept) != 1 {
+		t.Errorf("slept again %v for a recent baseline", slept[1:])
+	}
+	if math.Abs(load.CPUPercent-50) > 0.1 || load.DiskKBps != 0 || load.NetKBps != 0 {
+		t.Errorf("Load() = %v, want cpu 50%% without I/O", load)
+	}
+}
+
+func TestProcMonitorMissingFile(t *testing.T) {
+	p, m := newFakeProc(t)
+	m.sleep = func(time.Duration) {}
+	os.Remove(filepath.Join(p.root, "proc/net/dev"))
+	if _, err := m.Load(); err == nil {
+		t.Errorf("Load() without /proc/net/dev = nil error")
+	}
+}
@@ -0,0 +1,35 @@
+// Package alarm waits for a time of the system clock. Unlike time.Timer, which
+// counts the time the process runs, an alarm fires on time after the system clock
+// was set or the machine was suspended, and it fires early when either happens so
+// the caller can re-check its schedule. On Linux both are noticed at once; on Windows
+// a resume is, and a change of the clock within ClockCheck.
+package alarm
+
+import "time"
+
+// ClockCheck is how often an alarm on Windows compares the system clock with the
+// monotonic clock to notice that the clock was set. Windows only reports this with
+// WM_TIMECHANGE to the windows of the desktop, which a service does not have.
+const ClockCheck = time.Minute
+
+// clockTolerance is how far the two clocks may drift apart before the system clock
+// counts as set
+const clockTolerance = time.Second
+
+// clockSet reports whether the system clock was set, judged by how far the wall-clock
+// time that passed differs from the elapsed monotonic time
+func clockSet(wall, elapsed time.Duration) bool {
+	d := wall - elapsed
+	return d > clockTolerance || d < -clockTolerance
+}
+
+// fallback waits with a regular timer where the platform alarm is not available;
+// it neither follows changes of the system clock nor notices a resume
+func fallback(t time.Time) (<-chan time.Time, func()) {
+	c := make(chan time.Time, 1)
+	if t.IsZero() {
+		return c, func() {}
+	}
+	timer := time.AfterFunc(time.Until(t), func() { c <- time.Now() })
+	return c, func() { timer.Stop() }
+}
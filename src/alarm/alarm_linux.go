@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package alarm
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"codans.com/autoshut/src/applog"
+)
+
+const (
+	clockRealtime = 0
+	// timerfd_settime 的标志：绝对时间，系统时间被修改或从挂起恢复时返回 ECANCELED
+	tfdTimerAbstime     = 1
+	tfdTimerCancelOnSet = 2
+)
+
+type itimerspec struct {
+	interval syscall.Timespec
+	value    syscall.Timespec
+}
+
+// never is the expiry of an alarm that only waits for a clock change or a resume
+var never = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// At returns a channel that receives once the system clock reaches t, or earlier when
+// the clock is set or the machine resumes. The zero t waits for those only. stop
+// releases the alarm.
+func At(t time.Time) (c <-chan time.Time, stop func()) {
+	// 非阻塞的 timerfd 由运行时轮询，关闭文件即可结束等待
+	fd, _, errno := syscall.Syscall(syscall.SYS_TIMERFD_CREATE, clockRealtime, syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if errno != 0 {
+		applog.Debugf("timerfd_create: %v", errno)
+		return fallback(t)
+	}
+	if t.IsZero() {
+		t = never
+	}
+	spec := itimerspec{value: syscall.NsecToTimespec(t.UnixNano())}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_TIMERFD_SETTIME, fd, tfdTimerAbstime|tfdTimerCancelOnSet,
+		uintptr(unsafe.Pointer(&spec)), 0, 0, 0); errno != 0 {
+		syscall.Close(int(fd))
+		applog.Debugf("timerfd_settime: %v", errno)
+		return fallback(t)
+	}
+
+	f := os.NewFile(fd, "timerfd")
+	ch := make(chan time.Time, 1)
+	go func() {
+		var expirations [8]byte
+		if _, err := f.Read(expirations[:]); errors.Is(err, os.ErrClosed) {
+			return
+		}
+		ch <- time.Now()
+	}()
+	return ch, func() { f.Close() }
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package alarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAt(t *testing.T) {
+	c, stop := At(time.Now().Add(50 * time.Millisecond))
+	defer stop()
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatal("alarm did not fire")
+	}
+
+	// 已经过去的时间立即触发
+	c, stop = At(time.Now().Add(-time.Hour))
+	defer stop()
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatal("alarm in the past did not fire")
+	}
+}
+
+func TestAtStop(t *testing.T) {
+	c, stop := At(time.Now().Add(50 * time.Millisecond))
+	stop()
+	stop()
+	select {
+	case <-c:
+		t.Error("stopped alarm fired")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	c, stop = At(time.Time{})
+	stop()
+	select {
+	case <-c:
+		t.Error("alarm without a time fired")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,25 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSet(t *testing.T) {
+	tests := []struct {
+		name          string
+		wall, elapsed time.Duration
+		want          bool
+	}{
+		{"unchanged", time.Minute, time.Minute, false},
+		{"drift", time.Minute + 300*time.Millisecond, time.Minute, false},
+		{"set forward", time.Hour, time.Minute, true},
+		{"set back", -time.Hour, time.Minute, true},
+		{"set back a little", 58 * time.Second, time.Minute, true},
+	}
+	for _, tt := range tests {
+		if got := clockSet(tt.wall, tt.elapsed); got != tt.want {
+			t.Errorf("%s: clockSet(%v, %v) = %v, want %v", tt.name, tt.wall, tt.elapsed, got, tt.want)
+		}
+	}
+}
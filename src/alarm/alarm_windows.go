@@ -0,0 +1,141 @@
+//go:build windows
+// +build windows
+
+package alarm
+
+import (
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"codans.com/autoshut/src/applog"
+)
+
+var (
+	kernel32                                   = syscall.NewLazyDLL("kernel32.dll")
+	powrprof                                   = syscall.NewLazyDLL("powrprof.dll")
+	procCreateWaitableTimerW                   = kernel32.NewProc("CreateWaitableTimerW")
+	procSetWaitableTimer                       = kernel32.NewProc("SetWaitableTimer")
+	procCreateEventW                           = kernel32.NewProc("CreateEventW")
+	procSetEvent                               = kernel32.NewProc("SetEvent")
+	procWaitForMultipleObjects                 = kernel32.NewProc("WaitForMultipleObjects")
+	procPowerRegisterSuspendResumeNotification = powrprof.NewProc("PowerRegisterSuspendResumeNotification")
+)
+
+const (
+	// fileTimeEpoch is the offset between 1601-01-01 (FILETIME) and the Unix epoch in 100ns units
+	fileTimeEpoch = 116444736000000000
+
+	deviceNotifyCallback  = 2
+	pbtAPMResumeSuspend   = 0x7
+	pbtAPMResumeAutomatic = 0x12
+	waitObject0           = 0
+	waitTimeout           = 0x102
+)
+
+// 恢复通知在进程内只注册一次，回调唤醒所有正在等待的闹钟
+var (
+	resumeOnce   sync.Once
+	resumeMu     sync.Mutex
+	resumeEvents = map[syscall.Handle]bool{}
+	resumeParams struct {
+		callback uintptr
+		context  uintptr
+	}
+	resumeRegistration uintptr
+)
+
+func registerResume() {
+	// Windows 7 没有这个函数，只能依靠计时器本身
+	if procPowerRegisterSuspendResumeNotification.Find() != nil {
+		return
+	}
+	resumeParams.callback = syscall.NewCallback(func(context, typ, setting uintptr) uintptr {
+		if typ == pbtAPMResumeSuspend || typ == pbtAPMResumeAutomatic {
+			resumeMu.Lock()
+			for h := range resumeEvents {
+				procSetEvent.Call(uintptr(h))
+			}
+			resumeMu.Unlock()
+		}
+		return 0
+	})
+	if r, _, err := procPowerRegisterSuspendResumeNotification.Call(deviceNotifyCallback,
+		uintptr(unsafe.Pointer(&resumeParams)), uintptr(unsafe.Pointer(&resumeRegistration))); r != 0 {
+		applog.Debugf("PowerRegisterSuspendResumeNotification: %v", err)
+	}
+}
+
+func createEvent() syscall.Handle {
+	// 手动重置，初始无信号
+	h, _, _ := procCreateEventW.Call(0, 1, 0, 0)
+	return syscall.Handle(h)
+}
+
+// At returns a channel that receives once the system clock reaches t, or earlier when
+// the machine resumes or the clock is set. An absolute waitable timer fires once the
+// clock reaches t, also after it was set forward; setting it back is noticed within
+// ClockCheck. The zero t waits for a resume or a change of the clock. stop releases
+// the alarm.
+func At(t time.Time) (c <-chan time.Time, stop func()) {
+	resumeOnce.Do(registerResume)
+
+	h, _, err := procCreateWaitableTimerW.Call(0, 1, 0)
+	if h == 0 {
+		applog.Debugf("CreateWaitableTimer: %v", err)
+		return fallback(t)
+	}
+	timer := syscall.Handle(h)
+	if !t.IsZero() {
+		// 正数表示绝对时间（UTC FILETIME），不唤醒电脑
+		due := t.UnixNano()/100 + fileTimeEpoch
+		if r, _, err := procSetWaitableTimer.Call(h, uintptr(unsafe.Pointer(&due)), 0, 0, 0, 0); r == 0 {
+			syscall.CloseHandle(timer)
+			applog.Debugf("SetWaitableTimer: %v", err)
+			return fallback(t)
+		}
+	}
+	resumed, stopped := createEvent(), createEvent()
+	resumeMu.Lock()
+	resumeEvents[resumed] = true
+	resumeMu.Unlock()
+
+	var mu sync.Mutex
+	done := false
+	ch := make(chan time.Time, 1)
+	go func() {
+		handles := []syscall.Handle{timer, resumed, stopped}
+		start := time.Now()
+		var r uintptr
+		for {
+			r, _, _ = procWaitForMultipleObjects.Call(uintptr(len(handles)), uintptr(unsafe.Pointer(&handles[0])), 0,
+				uintptr(ClockCheck/time.Millisecond))
+			// 修改系统时间时计时器不会提前触发，定期比较墙上时间和单调时间
+			if r != waitTimeout || clockSet(time.Now().Round(0).Sub(start.Round(0)), time.Since(start)) {
+				break
+			}
+		}
+
+		resumeMu.Lock()
+		delete(resumeEvents, resumed)
+		resumeMu.Unlock()
+		mu.Lock()
+		done = true
+		for _, h := range handles {
+			syscall.CloseHandle(h)
+		}
+		mu.Unlock()
+
+		if r == waitObject0 || r == waitObject0+1 || r == waitTimeout {
+			ch <- time.Now()
+		}
+	}()
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done {
+			procSetEvent.Call(uintptr(stopped))
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package applog holds the debug logging switch shared by all AutoShutdown packages
+package applog
+
+import (
+	"log"
+)
+
+// Debug enables detailed logging, set from the -debug flag
+var Debug bool = false
+
+// Debugf logs a "[DEBUG]" prefixed message when debug mode is enabled
+func Debugf(format string, args ...interface{}) {
+	if !Debug {
+		return
+	}
+	log.Printf("[DEBUG] "+format, args...)
+}
@@ -0,0 +1,121 @@
+// Package calendar loads holiday and exception dates from iCalendar (.ics) files
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Action tells the scheduler how to treat a date covered by an exception
+type Action string
+
+const (
+	ActionSkip    Action = "skip"    // Do not enforce any window
+	ActionWeekend Action = "weekend" // Evaluate the windows as if it were Saturday
+	ActionWindow  Action = "window"  // Replace the day's windows with a custom one
+)
+
+// ParseAction validates an action name
+func ParseAction(value string) (Action, error) {
+	switch a := Action(strings.ToLower(strings.TrimSpace(value))); a {
+	case ActionSkip, ActionWeekend, ActionWindow:
+		return a, nil
+	default:
+		return "", fmt.Errorf("invalid calendar action %q, expected skip, weekend or window", value)
+	}
+}
+
+// Exception is one event of an ICS file, covering the dates [Start, End)
+type Exception struct {
+	Summary string
+	Start   time.Time // First date, midnight UTC
+	End     time.Time // Date after the last one, midnight UTC
+	Action  Action
+	Window  string // Custom window "HH:MM-HH:MM" for ActionWindow
+	Mode    string // Optional operation mode for ActionWindow
+	Source  string // File the exception was loaded from
+}
+
+// Covers reports whether the calendar date of day lies inside the exception
+func (e Exception) Covers(day time.Time) bool {
+	d := DateOf(day)
+	return !d.Before(e.Start) && d.Before(e.End)
+}
+
+// LastDay returns the last date covered by the exception
+func (e Exception) LastDay() time.Time {
+	return e.End.AddDate(0, 0, -1)
+}
+
+// DateOf returns the calendar date of t (in t's own location) as midnight UTC
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Source names an ICS file and the action used for events that don't set their own
+type Source struct {
+	Path   string `json:"path"`
+	Action Action `json:"action"`
+}
+
+// Calendar is the set of exceptions loaded from all configured files
+type Calendar struct {
+	Exceptions []Exception
+}
+
+// Load reads every source into a single Calendar, with event times converted to loc
+func Load(sources []Source, loc *time.Location) (*Calendar, error) {
+	cal := &Calendar{}
+	for _, src := range sources {
+		f, err := os.Open(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		action := src.Action
+		if action == "" {
+			action = ActionSkip
+		}
+		exceptions, err := Parse(f, action, loc)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", src.Path, err)
+		}
+		for i := range exceptions {
+			exceptions[i].Source = src.Path
+		}
+		cal.Exceptions = append(cal.Exceptions, exceptions...)
+	}
+	return cal, nil
+}
+
+// Lookup returns the first exception that covers day
+func (c *Calendar) Lookup(day time.Time) (Exception, bool) {
+	if c == nil {
+		return Exception{}, false
+	}
+	for _, e := range c.Exceptions {
+		if e.Covers(day) {
+			return e, true
+		}
+	}
+	return Exception{}, false
+}
+
+// Upcoming returns the exceptions that cover any date from 'from' up to and including 'until', sorted by start date
+func (c *Calendar) Upcoming(from, until time.Time) []Exception {
+	if c == nil {
+		return nil
+	}
+	first, last := DateOf(from), DateOf(until)
+	var result []Exception
+	for _, e := range c.Exceptions {
+		if e.End.After(first) && !e.Start.After(last) {
+			result = append(result, e)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Properties understood on a VEVENT besides DTSTART, DTEND and SUMMARY
+const (
+	propAction = "X-AUTOSHUTDOWN-ACTION" // skip, weekend or window
+	propWindow = "X-AUTOSHUTDOWN-WINDOW" // HH:MM-HH:MM, for the window action
+	propMode   = "X-AUTOSHUTDOWN-MODE"   // Operation mode, for the window action
+)
+
+// property is one unfolded content line: NAME;PARAM=VALUE:VALUE
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs of an iCalendar stream. Events without an
+// X-AUTOSHUTDOWN-ACTION property get defaultAction. Times are converted to loc,
+// the zone the windows are evaluated in, to find the dates an event covers.
+// Recurrence rules are not expanded; only the first occurrence is used.
+func Parse(r io.Reader, defaultAction Action, loc *time.Location) ([]Exception, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var exceptions []Exception
+	var event []property
+	inEvent := false
+	for n, line := range lines {
+		prop, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			inEvent = true
+			event = nil
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if !inEvent {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", n+1)
+			}
+			inEvent = false
+			e, err := buildException(event, defaultAction, loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			exceptions = append(exceptions, e)
+		case inEvent:
+			event = append(event, prop)
+		}
+	}
+	return exceptions, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (property, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return property{}, false
+	}
+	head := strings.Split(line[:colon], ";")
+	prop := property{name: strings.ToUpper(head[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, p := range head[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prop, true
+}
+
+func buildException(props []property, defaultAction Action, loc *time.Location) (Exception, error) {
+	e := Exception{Action: defaultAction}
+	var start, end time.Time
+	var startIsDate bool
+	for _, p := range props {
+		var err error
+		switch p.name {
+		case "SUMMARY":
+			e.Summary = unescape(p.value)
+		case "DTSTART":
+			start, startIsDate, err = parseDate(p, loc)
+		case "DTEND":
+			end, _, err = parseDate(p, loc)
+		case propAction:
+			e.Action, err = ParseAction(p.value)
+		case propWindow:
+			e.Window = strings.TrimSpace(p.value)
+		case propMode:
+			e.Mode = strings.ToLower(strings.TrimSpace(p.value))
+		}
+		if err != nil {
+			return Exception{}, err
+		}
+	}
+
+	if start.IsZero() {
+		return Exception{}, fmt.Errorf("event %q has no DTSTART", e.Summary)
+	}
+	if e.Action == ActionWindow && e.Window == "" {
+		return Exception{}, fmt.Errorf("event %q uses the window action without %s", e.Summary, propWindow)
+	}
+
+	e.Start = DateOf(start)
+	switch {
+	case end.IsZero() || !end.After(start):
+		// 没有结束时间时只覆盖开始当天
+		e.End = e.Start.AddDate(0, 0, 1)
+	case startIsDate:
+		// 全天事件的 DTEND 不包含在内
+		e.End = DateOf(end)
+	default:
+		// 带时间的事件覆盖它经过的每一天
+		e.End = DateOf(end.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+	}
+	return e, nil
+}
+
+// parseDate handles DATE values and DATE-TIME values in UTC, a TZID or floating time,
+// which is taken to be in loc. The result is in loc; the bool result is true for DATE values.
+func parseDate(p property, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t.In(loc), false, err
+	}
+
+	zone := loc
+	if tzid := p.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		zone = l
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, zone)
+	return t.In(loc), false, err
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
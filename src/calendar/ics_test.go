@@ -0,0 +1,66 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimesInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:UTC evening",
+		"DTSTART:20261224T170000Z",
+		"DTEND:20261224T180000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Floating",
+		"DTSTART:20261226T230000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:New York",
+		"DTSTART;TZID=America/New_York:20261231T200000",
+		"DTEND;TZID=America/New_York:20261231T210000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:All day",
+		"DTSTART;VALUE=DATE:20270101",
+		"DTEND;VALUE=DATE:20270103",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	exceptions, err := Parse(strings.NewReader(ics), ActionSkip, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	// 时间按配置的时区换算日期，而不是系统时区
+	want := []struct {
+		summary    string
+		start, end time.Time
+	}{
+		{"UTC evening", date(12, 25), date(12, 26)},
+		{"Floating", date(12, 26), date(12, 27)},
+		{"New York", date(13, 1), date(13, 2)},
+		{"All day", date(13, 1), date(13, 3)},
+	}
+	if len(exceptions) != len(want) {
+		t.Fatalf("Parse() = %d exceptions, want %d", len(exceptions), len(want))
+	}
+	for i, w := range want {
+		e := exceptions[i]
+		if e.Summary != w.summary || !e.Start.Equal(w.start) || !e.End.Equal(w.end) {
+			t.Errorf("exception %d = %s %s-%s, want %s %s-%s", i, e.Summary,
+				e.Start.Format("2006-01-02"), e.End.Format("2006-01-02"),
+				w.summary, w.start.Format("2006-01-02"), w.end.Format("2006-01-02"))
+		}
+	}
+}
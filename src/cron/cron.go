@@ -0,0 +1,227 @@
+// Package cron parses standard 5-field cron expressions and computes their next run time
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression: minute hour day-of-month month day-of-week.
+// As in Vixie cron, if both day fields are restricted a day matches when either does.
+// The day-of-week field also accepts "DOW#N" for the Nth such weekday of the month.
+type Schedule struct {
+	expr    string
+	minute  uint64 // bit i set: minute i matches
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	nth     map[time.Weekday][]int // Weekdays restricted to the Nth occurrence in the month
+	domStar bool
+	dowStar bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Parse parses a 5-field expression or one of the @yearly, @monthly, @weekly, @daily, @midnight and @hourly macros
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := strings.ToLower(expr)
+	if m, ok := macros[spec]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr, nth: map[time.Weekday][]int{}}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %v", expr, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %v", expr, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %v", expr, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %v", expr, err)
+	}
+	if err = s.parseDow(fields[4]); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %v", expr, err)
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// parseDow handles the day-of-week field, including 7 for Sunday and the DOW#N form
+func (s *Schedule) parseDow(field string) error {
+	var plain []string
+	for _, part := range strings.Split(field, ",") {
+		hash := strings.Index(part, "#")
+		if hash < 0 {
+			plain = append(plain, part)
+			continue
+		}
+		day, err := parseValue(part[:hash], 0, 7, dayNames)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(part[hash+1:])
+		if err != nil || n < 1 || n > 5 {
+			return fmt.Errorf("invalid occurrence in %q, expected 1-5", part)
+		}
+		wd := time.Weekday(day % 7)
+		s.nth[wd] = append(s.nth[wd], n)
+	}
+	if len(plain) > 0 {
+		bits, err := parseField(strings.Join(plain, ","), 0, 7, dayNames)
+		if err != nil {
+			return err
+		}
+		// 7 和 0 都表示星期日
+		if bits&(1<<7) != 0 {
+			bits |= 1
+		}
+		s.dow = bits
+	}
+	return nil
+}
+
+// parseField turns a comma separated list of values, ranges and steps into a bit set
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			n, err := strconv.Atoi(part[slash+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:slash]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], min, max, names); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(bounds[1], min, max, names); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := parseValue(part, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" 表示从 5 开始每 15 个单位
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if value == name {
+			return i + min, nil
+		}
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q, expected %d-%d", value, min, max)
+	}
+	return v, nil
+}
+
+// dayMatches applies the day-of-month / day-of-week rules to a date
+func (s *Schedule) dayMatches(t time.Time) bool {
+	if s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	for _, n := range s.nth[t.Weekday()] {
+		if (t.Day()-1)/7+1 == n {
+			dowMatch = true
+		}
+	}
+
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// Next returns the first matching minute strictly after t, or the zero time if
+// nothing matches within five years (e.g. "0 0 30 2 *")
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	y, mo, d := t.Date()
+	hour, minute := t.Hour(), t.Minute()+1
+
+	for i := 0; i < 366*5; i++ {
+		day := time.Date(y, mo, d+i, 0, 0, 0, 0, loc)
+		if i > 0 {
+			hour, minute = 0, 0
+		}
+		if !s.dayMatches(day) {
+			continue
+		}
+		for h := hour; h < 24; h++ {
+			if s.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			m := 0
+			if h == hour {
+				m = minute
+			}
+			for ; m < 60; m++ {
+				if s.minute&(1<<uint(m)) != 0 {
+					return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, loc)
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,110 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2026-10-18 是星期日
+	from := time.Date(2026, 10, 18, 14, 30, 0, 0, time.UTC)
+	at := func(y int, m time.Month, d, hour, minute int) time.Time {
+		return time.Date(y, m, d, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"hourly", "@hourly", at(2026, 10, 18, 15, 0)},
+		{"daily", "@daily", at(2026, 10, 19, 0, 0)},
+		{"midnight", "@midnight", at(2026, 10, 19, 0, 0)},
+		{"weekly", "@weekly", at(2026, 10, 25, 0, 0)},
+		{"monthly", "@monthly", at(2026, 11, 1, 0, 0)},
+		{"yearly", "@yearly", at(2027, 1, 1, 0, 0)},
+		{"annually", "@annually", at(2027, 1, 1, 0, 0)},
+		{"macro with spaces and capitals", "  @Daily ", at(2026, 10, 19, 0, 0)},
+		{"every minute", "* * * * *", at(2026, 10, 18, 14, 31)},
+		{"step", "*/15 * * * *", at(2026, 10, 18, 14, 45)},
+		{"step from a value", "5/20 * * * *", at(2026, 10, 18, 14, 45)},
+		{"range with step", "0 9-17/4 * * *", at(2026, 10, 18, 17, 0)},
+		{"list", "10,20 15,16 * * *", at(2026, 10, 18, 15, 10)},
+		{"day range by name", "30 22 * * mon-fri", at(2026, 10, 19, 22, 30)},
+		{"day list by name", "0 8 * * sat,sun", at(2026, 10, 24, 8, 0)},
+		{"upper case names", "0 0 * * MON", at(2026, 10, 19, 0, 0)},
+		{"7 is sunday", "0 8 * * 7", at(2026, 10, 25, 8, 0)},
+		{"month by name", "0 0 1 jan *", at(2027, 1, 1, 0, 0)},
+		{"month range by name", "0 12 * nov-dec *", at(2026, 11, 1, 12, 0)},
+		{"last minute of the year", "59 23 31 12 *", at(2026, 12, 31, 23, 59)},
+		{"leap day", "0 0 29 2 *", at(2028, 2, 29, 0, 0)},
+		{"first sunday", "0 0 * * sun#1", at(2026, 11, 1, 0, 0)},
+		{"second or fourth friday", "0 20 * * fri#2,fri#4", at(2026, 10, 23, 20, 0)},
+		{"fifth monday", "0 0 * * 1#5", at(2026, 11, 30, 0, 0)},
+		{"nth and plain weekday", "0 0 * * sat,sun#1", at(2026, 10, 24, 0, 0)},
+		{"day of month only", "0 0 13 * *", at(2026, 11, 13, 0, 0)},
+		{"day of month or weekday", "0 0 13 * fri", at(2026, 10, 23, 0, 0)},
+		{"weekday or day of month", "0 0 20 * sat", at(2026, 10, 20, 0, 0)},
+		{"question mark", "0 0 ? * mon", at(2026, 10, 19, 0, 0)},
+		{"never", "0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %v", tt.expr, err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.expr, from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextIsAfter(t *testing.T) {
+	s, err := Parse("30 14 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 正好在匹配的时间时返回下一次
+	from := time.Date(2026, 10, 18, 14, 30, 0, 0, time.UTC)
+	if got, want := s.Next(from), from.AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+	if got := s.Next(from.Add(-30 * time.Second)); !got.Equal(from) {
+		t.Errorf("Next(%v) = %v, want %v", from.Add(-30*time.Second), got, from)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@reboot",
+		"60 * * * *",
+		"-1 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"a * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"* * * dec-feb *",
+		"1-x * * * *",
+		"* * * foo *",
+		"* * * * funday",
+		"* * * * mon#0",
+		"* * * * mon#6",
+		"* * * * mon#x",
+		"* * * * noday#1",
+	} {
+		if s, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", expr, s)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package extension grants snoozes of the scheduled operation and keeps a record of every request
+package extension
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/fsutil"
+)
+
+// MaxRecords is the number of requests kept in the record, older ones are dropped
+const MaxRecords = 100
+
+// Config is the "extensions" section of the config file
+type Config struct {
+	PerNight int `json:"per_night"` // Extensions granted without approval each night
+	Minutes  int `json:"minutes"`   // Length of one extension, 0 disables extensions
+	// PIN a parent gives with "extend approve" and "extend deny". The remote commands
+	// are not authenticated, so without it requests beyond the allowance stay pending.
+	ApprovalPIN string `json:"approval_pin,omitempty"`
+}
+
+// Enabled reports whether extensions can be requested
+func (c Config) Enabled() bool {
+	return c.Minutes > 0
+}
+
+// Authorized reports whether pin is the configured approval PIN, ignoring case
+func (c Config) Authorized(pin string) bool {
+	if c.ApprovalPIN == "" {
+		return false
+	}
+	want := strings.ToLower(c.ApprovalPIN)
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(pin)), []byte(want)) == 1
+}
+
+// Status is the outcome of a request
+type Status string
+
+const (
+	Granted  Status = "granted"  // Within the nightly allowance
+	Pending  Status = "pending"  // Waiting for a parent to approve or deny it
+	Approved Status = "approved" // Approved by a parent
+	Denied   Status = "denied"   // Denied by a parent
+	Expired  Status = "expired"  // Not answered before the operation ran
+)
+
+// Request is one extension request
+type Request struct {
+	ID      int       `json:"id"`
+	Night   string    `json:"night"` // 2006-01-02 on which the window started
+	Time    time.Time `json:"time"`
+	Mode    string    `json:"mode"`
+	Minutes int       `json:"minutes"`
+	Status  Status    `json:"status"`
+	Decided time.Time `json:"decided,omitempty"`
+	Applied bool      `json:"applied,omitempty"` // The scheduled time has been moved
+}
+
+// Effective reports whether the request moves the scheduled time
+func (r Request) Effective() bool {
+	return r.Status == Granted || r.Status == Approved
+}
+
+// state is the persisted record
+type state struct {
+	NextID   int       `json:"next_id"`
+	Requests []Request `json:"requests"`
+}
+
+// Ledger records the requests and decides whether they are granted
+type Ledger struct {
+	mu       sync.Mutex
+	path     string
+	nextID   int
+	requests []Request
+}
+
+// Open loads the record from path. A missing file starts an empty record.
+func Open(path string) (*Ledger, error) {
+	l := &Ledger{path: path, nextID: 1}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if st.NextID > l.nextID {
+		l.nextID = st.NextID
+	}
+	l.requests = st.Requests
+	return l, nil
+}
+
+// Request records a request for the given night. It is granted while the nightly
+// allowance lasts and otherwise waits for approval.
+func (l *Ledger) Request(now time.Time, night, mode string, cfg Config) (Request, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := Request{ID: l.nextID, Night: night, Time: now, Mode: mode, Minutes: cfg.Minutes, Status: Pending}
+	if l.used(night) < cfg.PerNight {
+		r.Status = Granted
+		r.Decided = now
+	}
+	l.nextID++
+	l.requests = append(l.requests, r)
+	if len(l.requests) > MaxRecords {
+		l.requests = l.requests[len(l.requests)-MaxRecords:]
+	}
+	return r, l.save()
+}
+
+// Used returns the number of extensions granted from the allowance of night
+func (l *Ledger) Used(night string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used(night)
+}
+
+func (l *Ledger) used(night string) int {
+	n := 0
+	for _, r := range l.requests {
+		if r.Night == night && r.Status == Granted {
+			n++
+		}
+	}
+	return n
+}
+
+// Approve approves a pending request. It reports false if there is no pending request with that ID.
+func (l *Ledger) Approve(id int, now time.Time) (Request, bool, error) {
+	return l.decide(id, now, Approved)
+}
+
+// Deny denies a pending request. It reports false if there is no pending request with that ID.
+func (l *Ledger) Deny(id int, now time.Time) (Request, bool, error) {
+	return l.decide(id, now, Denied)
+}
+
+func (l *Ledger) decide(id int, now time.Time, status Status) (Request, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.requests {
+		r := &l.requests[i]
+		if r.ID == id && r.Status == Pending {
+			r.Status = status
+			r.Decided = now
+			return *r, true, l.save()
+		}
+	}
+	return Request{}, false, nil
+}
+
+// TakeEffective returns the granted and approved requests of night that have not
+// moved the scheduled time yet, and marks them as applied
+func (l *Ledger) TakeEffective(night string) ([]Request, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var taken []Request
+	for i := range l.requests {
+		r := &l.requests[i]
+		if r.Night == night && r.Effective() && !r.Applied {
+			r.Applied = true
+			taken = append(taken, *r)
+		}
+	}
+	if len(taken) == 0 {
+		return nil, nil
+	}
+	return taken, l.save()
+}
+
+// Expire marks the pending requests of night as expired, once the operation has run
+func (l *Ledger) Expire(night string, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed := false
+	for i := range l.requests {
+		r := &l.requests[i]
+		if r.Night == night && r.Status == Pending {
+			r.Status = Expired
+			r.Decided = now
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// List returns a copy of the recorded requests, oldest first
+func (l *Ledger) List() []Request {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Request(nil), l.requests...)
+}
+
+func (l *Ledger) save() error {
+	if l.path == "" {
+		return nil
+	}
+	requests := l.requests
+	if requests == nil {
+		requests = []Request{}
+	}
+	data, err := json.MarshalIndent(state{NextID: l.nextID, Requests: requests}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(l.path, data, 0644)
+}
@@ -0,0 +1,188 @@
+// Package history records the performed operations and the escalation steps each of them reached
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/fsutil"
+)
+
+// MaxEntries is the number of operations kept, older ones are dropped
+const MaxEntries = 100
+
+// Result is the outcome of one step
+type Result string
+
+const (
+	Started     Result = "started"     // Performed, the outcome is not known yet; stays so if the machine went down
+	Confirmed   Result = "confirmed"   // The power state changed
+	Failed      Result = "failed"      // The system refused the operation
+	Unconfirmed Result = "unconfirmed" // The power state did not change within the verification time
+	Stopped     Result = "stopped"     // The service was stopped while waiting, usually by the shutdown itself
+)
+
+// Step is one attempt of the escalation chain
+type Step struct {
+	Mode   string    `json:"mode"`
+	Time   time.Time `json:"time"`
+	Result Result    `json:"result"`
+	Error  string    `json:"error,omitempty"`
+	// Applications that held the step back, e.g. with unsaved work
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// Entry is one operation
+type Entry struct {
+	ID     int       `json:"id"`
+	Time   time.Time `json:"time"`
+	Mode   string    `json:"mode"`
+	Reason string    `json:"reason"`
+	Steps  []Step    `json:"steps,omitempty"`
+}
+
+// Reached returns the last step that was tried
+func (e Entry) Reached() (Step, bool) {
+	if len(e.Steps) == 0 {
+		return Step{}, false
+	}
+	return e.Steps[len(e.Steps)-1], true
+}
+
+// state is the persisted history
+type state struct {
+	NextID  int     `json:"next_id"`
+	Entries []Entry `json:"entries"`
+}
+
+// Log holds the recent operations, oldest first
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	nextID  int
+	entries []Entry
+}
+
+// Open loads the history from path. A missing file starts empty.
+func Open(path string) (*Log, error) {
+	l := &Log{path: path, nextID: 1}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if st.NextID > l.nextID {
+		l.nextID = st.NextID
+	}
+	l.entries = st.Entries
+	return l, nil
+}
+
+// Begin adds an operation without steps and returns its ID
+func (l *Log) Begin(at time.Time, mode, reason string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := Entry{ID: l.nextID, Time: at, Mode: mode, Reason: reason}
+	l.nextID++
+	l.entries = append(l.entries, e)
+	if len(l.entries) > MaxEntries {
+		l.entries = l.entries[len(l.entries)-MaxEntries:]
+	}
+	return e.ID, l.save()
+}
+
+// Step adds a started step to operation id. It is saved before the step is
+// performed, so an operation that took the machine down still shows how far it got.
+func (l *Log) Step(id int, mode string, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.find(id)
+	if e == nil {
+		return nil
+	}
+	e.Steps = append(e.Steps, Step{Mode: mode, Time: at, Result: Started})
+	return l.save()
+}
+
+// Finish sets the result of the last step of operation id
+func (l *Log) Finish(id int, result Result, err error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.find(id)
+	if e == nil || len(e.Steps) == 0 {
+		return nil
+	}
+	s := &e.Steps[len(e.Steps)-1]
+	s.Result = result
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return l.save()
+}
+
+// Blocked adds who to the applications holding back the last step of operation id,
+// unless it is listed already
+func (l *Log) Blocked(id int, who string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.find(id)
+	if e == nil || len(e.Steps) == 0 {
+		return nil
+	}
+	s := &e.Steps[len(e.Steps)-1]
+	for _, b := range s.Blockers {
+		if b == who {
+			return nil
+		}
+	}
+	s.Blockers = append(s.Blockers, who)
+	return l.save()
+}
+
+// List returns a copy of the operations, oldest first
+func (l *Log) List() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]Entry, len(l.entries))
+	for i, e := range l.entries {
+		e.Steps = append([]Step(nil), e.Steps...)
+		for j := range e.Steps {
+			e.Steps[j].Blockers = append([]string(nil), e.Steps[j].Blockers...)
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+func (l *Log) find(id int) *Entry {
+	for i := range l.entries {
+		if l.entries[i].ID == id {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func (l *Log) save() error {
+	if l.path == "" {
+		return nil
+	}
+	st := state{NextID: l.nextID, Entries: l.entries}
+	if st.Entries == nil {
+		st.Entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(l.path, data, 0644)
+}
@@ -0,0 +1,133 @@
+// Package hook runs the configured commands before an operation and after the machine is back
+package hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout applies to hooks without timeout_seconds
+const DefaultTimeout = time.Minute
+
+// Failure policies
+const (
+	Continue = "continue" // Log the failure and go on, the default
+	Abort    = "abort"    // Skip the remaining hooks and, before the operation, the operation itself
+)
+
+// Phases
+const (
+	Before = "before"
+	After  = "after"
+)
+
+// Hook is one command
+type Hook struct {
+	Name           string   `json:"name,omitempty"`
+	Command        []string `json:"command"` // Program and arguments, not run through a shell
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+	OnFailure      string   `json:"on_failure,omitempty"`
+}
+
+// Timeout returns how long the hook may run
+func (h Hook) Timeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// Config is the "hooks" section of the config file
+type Config struct {
+	Before []Hook `json:"before,omitempty"` // Run before the operation
+	After  []Hook `json:"after,omitempty"`  // Run after resume or the next boot
+}
+
+// Validate checks the hooks and names those that have none
+func (c *Config) Validate() error {
+	for phase, hooks := range map[string][]Hook{Before: c.Before, After: c.After} {
+		for i := range hooks {
+			h := &hooks[i]
+			if h.Name == "" {
+				h.Name = fmt.Sprintf("%s%d", phase, i+1)
+			}
+			if len(h.Command) == 0 || h.Command[0] == "" {
+				return fmt.Errorf("hook %s: command is empty", h.Name)
+			}
+			if h.TimeoutSeconds < 0 {
+				return fmt.Errorf("hook %s: timeout_seconds must not be negative", h.Name)
+			}
+			switch h.OnFailure {
+			case "":
+				h.OnFailure = Continue
+			case Continue, Abort:
+			default:
+				return fmt.Errorf("hook %s: on_failure must be %q or %q", h.Name, Continue, Abort)
+			}
+		}
+	}
+	return nil
+}
+
+// Event describes the operation the hooks run for
+type Event struct {
+	Phase     string    `json:"phase"`
+	Mode      string    `json:"mode"`
+	Reason    string    `json:"reason"`
+	Scheduled time.Time `json:"scheduled,omitempty"`
+	Performed time.Time `json:"performed,omitempty"` // When the operation started, for the "after" hooks
+}
+
+// Env returns the environment variables describing e
+func (e Event) Env() []string {
+	return []string{
+		"AUTOSHUT_PHASE=" + e.Phase,
+		"AUTOSHUT_MODE=" + e.Mode,
+		"AUTOSHUT_REASON=" + e.Reason,
+		"AUTOSHUT_SCHEDULED=" + formatTime(e.Scheduled),
+		"AUTOSHUT_PERFORMED=" + formatTime(e.Performed),
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Run runs h for e and passes each line of its output to output.
+// The hook is killed once its timeout has passed.
+func Run(h Hook, e Event, output func(line string)) error {
+	// 输出写入临时文件而不是管道，超时结束进程后不会等待仍持有管道的子进程
+	out, err := os.CreateTemp("", "autoshut-hook-*.log")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(), e.Env()...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+
+	if data, readErr := os.ReadFile(out.Name()); readErr == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				output(line)
+			}
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", h.Timeout())
+	}
+	return err
+}
@@ -0,0 +1,599 @@
+// Package i18n provides internationalization support for AutoShutdown
+package i18n
+
+import (
+	"fmt"
+)
+
+// Language strings map
+var langStrings = map[string]map[string]string{
+	"en": {
+		// General
+		"app_name":     "AutoShutdown",
+		"developed_by": "Developed by Hu Gang (ihugang@gmail.com)",
+		"version_info": "%s version: %s (%s)",
+
+		// Operation modes
+		"mode_shutdown":               "Shutdown",
+		"mode_hibernate":              "Hibernate",
+		"mode_reboot":                 "Reboot",
+		"mode_logoff":                 "Logoff",
+		"mode_suspend":                "Suspend",
+		"mode_hybrid_sleep":           "Hybrid sleep",
+		"mode_suspend_then_hibernate": "Suspend then hibernate",
+		"mode_lock_session":           "Lock session",
+		"mode_display_off":            "Display off",
+		"mode_force_shutdown":         "Forced shutdown",
+		"mode_force_reboot":           "Forced reboot",
+
+		// Status messages
+		"current_status":          "Operation mode: %s | Version: %s",
+		"window_status":           "Window %s: %s %s - %s | %s",
+		"time_zone_status":        "Time zone: %s (now %s)",
+		"cron_status":             "Cron %s: %s | next %s | %s",
+		"idle_policy_status":      "Idle %s: %s %s-%s after %d min idle | %s",
+		"idle_time_status":        "User idle for %s",
+		"queue_disabled":            "One-off operations are unavailable",
+		"queue_usage":               "Usage: schedule in <45m|1h30m> [mode] | schedule at <HH:MM> [mode] | schedule list | schedule cancel <id>",
+		"queue_empty":               "No scheduled operations",
+		"queue_header":              "Scheduled operations:",
+		"queue_item":                "  #%d %s at %s (in %s)",
+		"queue_added":               "Scheduled #%d: %s at %s",
+		"queue_cancelled":           "Cancelled scheduled operation #%d",
+		"queue_not_found":           "No scheduled operation #%d",
+		"queue_save_failed":         "Failed to save the queue: %v",
+		"extension_disabled":        "No extension policy is configured",
+		"extension_usage":           "Usage: extend [list] | extend request | extend approve <id> <pin> | extend deny <id> <pin>",
+		"extension_status":          "Extensions: %d per night of %d min, %d used tonight",
+		"extension_item":            "  #%d %s %s +%d min: %s",
+		"extension_status_granted":  "granted",
+		"extension_status_pending":  "waiting for approval",
+		"extension_status_approved": "approved",
+		"extension_status_denied":   "denied",
+		"extension_status_expired":  "expired, not answered in time",
+		"extension_no_window":       "No window is active, there is nothing to extend",
+		"extension_granted":         "Extension #%d granted: the operation moves %d minutes later",
+		"extension_pending":         "Extension #%d needs a parent's approval (extend approve %d <pin>)",
+		"extension_approved":        "Extension #%d approved: the operation moves %d minutes later",
+		"extension_denied":          "Extension #%[1]d denied",
+		"extension_not_pending":     "No extension request #%d is waiting for approval",
+		"extension_no_pin":          "Set approval_pin in the extensions config to approve or deny requests",
+		"extension_wrong_pin":       "Wrong approval PIN",
+		"approval_no_pin":           "Set approval_pin in the extensions config to use %s",
+		"extension_save_failed":     "Failed to save the extension record: %v",
+		"extension_question":        "Ask for %d more minutes?",
+		"extension_remote_hint":     "To ask, send the remote command: extend request",
+		"lockout_disabled":          "Lockout is disabled",
+		"lockout_status":            "Lockout: powering on inside an enforced window repeats the operation after %s",
+		"lockout_none":              "No window has been enforced yet",
+		"lockout_record":            "Window %s (%s) enforced at %s, powered on again %d time(s) since",
+		"lockout_attempt":           "  powered on at %s",
+		"wake_unavailable":          "Wake timers are unavailable",
+		"wake_usage":                "Usage: wake [status] | wake at <HH:MM> [days] | wake off [days], days e.g. mon-fri or sat,sun",
+		"wake_none":                 "No wake times configured",
+		"wake_status":               "Wake %s at %s",
+		"wake_next":                 "Next wake-up: %s",
+		"wake_armed":                "Wake timer armed for %s",
+		"history_none":              "No operation has been recorded yet",
+		"history_entry":             "#%d %s  %s (%s)",
+		"history_step":              "  %s %s: %s",
+		"history_step_error":        "  %s %s: %s (%s)",
+		"history_started":           "performed",
+		"history_confirmed":         "confirmed",
+		"history_failed":            "failed",
+		"history_unconfirmed":       "not confirmed in time",
+		"history_stopped":           "service stopped",
+		"history_blocker":           "    held back by %s",
+		"simulate_usage":            "Usage: simulate [YYYY-MM-DD] [days], at most %d days",
+		"simulate_header":           "Simulated schedule %s - %s (%s), assuming the machine is on whenever a window starts:",
+		"simulate_none":             "No window is entered in this range",
+		"simulate_entry":            "%s  window %s (%s-%s): %s",
+		"simulate_warning":          "    warning   %s",
+		"simulate_operation":        "    operation %s",
+		"simulate_maybe":            "    operation %s, skipped if the delay outlasts the window",
+		"simulate_skipped":          "    no operation, the window ends before the delay",
+		"wake_not_armed":            "Wake timer not armed",
+		"wake_armed_unknown":        "Cannot read the wake timer: %v",
+		"wake_set":                  "Wake time set to %s on %s",
+		"wake_removed":              "Wake times removed on %s",
+		"tamper_disabled":           "Clock tamper detection is disabled",
+		"tamper_status":             "Clock tamper detection: response %s, threshold %s, trusted time offset from the wall clock %s",
+		"tamper_event":              "  %s",
+		"tamper_accepted":           "Current system time accepted as trusted time",
+		"tamper_usage":              "Usage: tamper [status] | tamper accept <pin>",
+		"processes_disabled":        "No process lists configured",
+		"processes_list_failed":     "Failed to list processes: %v",
+		"processes_allow":           "Allowlisted processes (defer up to %[2]d min): %[1]s",
+		"processes_deferring":       "Currently deferring the operation: %s",
+		"processes_not_deferring":   "No allowlisted process is running",
+		"processes_block":           "Blocklisted processes (terminated inside the windows): %s",
+		"processes_blocked_running": "Running blocklisted processes: %s",
+		"busy_status":             "Busy guard: postpone while %s, at most %d min",
+		"busy_decision":           "  %s %s (%s): %s | %s",
+		"busy_verdict_postponed":  "postponed",
+		"busy_verdict_proceed":    "executed",
+		"busy_verdict_limit":      "executed, postponement limit reached",
+		"busy_verdict_no-data":    "executed, load unknown",
+		"quota_status":            "Screen time today: %s used of %s (+%s granted), %s remaining",
+		"quota_granted":           "Granted %d extra minutes for today",
+		"quota_reset":             "Today's screen time has been reset",
+		"quota_disabled":          "No daily screen-time quota is configured",
+		"quota_usage":             "Usage: quota status | quota grant <minutes> <pin> | quota reset <pin>",
+		"scheduled_shutdown":      "Scheduled %s at %s",
+		"executing_operation":     "Executing %s operation...",
+		"operation_successful":    "%s operation successful",
+		"operation_failed":        "%s command failed: %v",
+		"shutdown_warning":        "WARNING: Computer will shut down in %d minutes (%s). Save your work now!",
+		"shutdown_warning_title":   "System %s Warning",
+		"shutdown_warning_cancel":  "Cancel",
+		"shutdown_warning_proceed": "Proceed",
+		"countdown":               "Countdown",
+		"ok_button":               "OK",
+		"shutdown_cancelled":       "%s operation cancelled",
+		"unknown_command":         "Unknown command. Use 'help' to see available commands",
+		"please_specify_language": "Please specify language code (en or zh-Hans)",
+		"invalid_time_type":       "Invalid time type. Please use 'start' or 'end'",
+		"system_status":           "System status: Running\nCurrent time: %s\nTime range: %02d:%02d - %02d:%02d\nOperation mode: %s",
+		"dry_run_status":          "Dry-run mode: %d operation(s) recorded",
+		"dry_run_record":          "  %s  %s (%s)",
+
+		// Command responses
+		"enter_command":       "Please enter a command",
+		"enter_start_time":    "Please enter start time (format HH:MM), e.g. 22:00",
+		"enter_end_time":      "Please enter end time (format HH:MM), e.g. 06:00",
+		"invalid_time_format": "Invalid time format. Please use HH:MM format, or sunrise/sunset with an offset such as sunset+30m.",
+		"time_set_success":    "%s time set to %02d:%02d",
+		"window_time_set_success": "Window %s: %s time set to %s",
+		"solar_needs_coordinates": "Sunrise and sunset times need coordinates in the config file or -latitude/-longitude",
+		"window_solar_today":      "(today %s-%s)",
+		"window_not_found":    "Unknown window: %s",
+		"upcoming_exceptions": "Calendar exceptions in the next %d days:",
+		"no_exceptions":       "No calendar exceptions in the next %d days",
+		"exception_item":      "  %s - %s  %s: %s",
+		"exception_action_skip":    "skip enforcement",
+		"exception_action_weekend": "weekend rules",
+		"exception_action_window":  "custom window",
+		"time_start":          "start",
+		"time_end":            "end",
+		"invalid_mode":        "Invalid mode. Available modes: shutdown, hibernate, reboot, logoff, suspend, hybrid-sleep, suspend-then-hibernate, lock-session, display-off",
+		"mode_set_success":    "Operation mode set to: %s",
+
+		// Menu
+		"welcome_title":       "===== AutoShutdown Remote Control =====",
+		"menu_item":              "%d. %s",
+		"menu_shutdown":          "Shutdown computer",
+		"menu_hibernate":         "Hibernate computer",
+		"menu_reboot":            "Restart computer",
+		"menu_logoff":            "Log off current user",
+		"menu_suspend":           "Suspend computer",
+		"menu_hybrid_sleep":      "Hybrid sleep",
+		"menu_suspend_hibernate": "Suspend, then hibernate",
+		"menu_lock_session":      "Lock the session",
+		"menu_display_off":       "Turn the display off",
+		"menu_status":            "View system status",
+		"menu_set_start_time":    "Set start time",
+		"menu_set_end_time":      "Set end time",
+		"menu_set_mode":          "Set operation mode",
+		"menu_language":          "Change language",
+		"menu_help":              "Show help",
+		"menu_exit":              "Exit",
+		"menu_prompt":            "Enter option number: ",
+
+		// Help text
+		"help_text": `Available commands:
+- shutdown: Shutdown computer
+- hibernate: Hibernate computer
+- reboot: Restart computer
+- logoff: Log off current user
+- suspend: Suspend computer to RAM
+- hybrid-sleep: Suspend computer to RAM and disk
+- suspend-then-hibernate: Suspend computer, hibernate it later
+- lock-session: Lock the current session
+- display-off: Turn the display off
+- setmode [mode]: Set operation mode (any of the modes above)
+- status: View system status
+- exceptions [days]: List upcoming calendar exceptions (default 30 days)
+- quota status|grant <minutes>|reset: Show, extend or reset today's screen time
+- processes: Show running processes that defer the operation or are blocked
+- schedule in <45m> [mode]: Run an operation once after a delay
+- schedule at <HH:MM> [mode]: Run an operation once at the next HH:MM
+- schedule list|cancel <id>: List or cancel one-off operations
+- extend [list]: Show the extension policy and recent requests
+- extend request: Ask for more time before the scheduled operation
+- extend approve|deny <id> <pin>: Approve or deny an extension beyond tonight's allowance
+- tamper [status|accept]: Show detected system clock changes, or accept the current time
+- lockout: Show enforced windows and attempts to power on again
+- history: Show recent operations and the escalation steps they reached
+- simulate [YYYY-MM-DD] [days]: Show what the schedule does in the next days (default 7)
+- wake [status]: Show the wake times and the armed wake timer
+- wake at <HH:MM> [days]: Wake the machine at HH:MM (every day, or e.g. mon-fri)
+- wake off [days]: Remove wake times
+- settime [window] start HH:MM: Set start time (first window if none given), also sunset+30m
+- settime [window] end HH:MM: Set end time (first window if none given), also sunrise-15m
+- language [code]: Change language (en/zh-Hans)
+- version: Show version information
+- help: Show help information`,
+
+		// Language
+		"language_changed":      "Language changed to: %s",
+		"language_name_en":      "English",
+		"language_name_zh-Hans": "Simplified Chinese",
+		"invalid_language":      "Invalid language code. Available languages: en, zh-Hans",
+
+		// Log messages
+		"log_tcp_server_started":  "TCP remote control server started, listening on port %s",
+		"log_udp_server_started":  "UDP remote control server started, listening on port %s",
+		"log_accept_failed":       "Failed to accept connection: %v",
+		"log_new_tcp_connection":  "New TCP connection from: %s",
+		"log_command_read_failed": "Failed to read command: %v",
+		"log_udp_addr_failed":     "Failed to resolve UDP address: %v",
+		"log_udp_listen_failed":   "Failed to start UDP server: %v",
+		"log_udp_read_failed":     "Failed to read UDP data: %v",
+		"log_udp_command":         "Received UDP command from %s: %s",
+		"log_service_installed":   "Service installed successfully",
+		"log_service_removed":     "Service removed successfully",
+		"log_service_stopped":     "Service stopped successfully",
+		"log_service_started":     "Service started successfully",
+		"log_dry_run_operation":   "[DRY-RUN] %s operation recorded (reason: %s), power state unchanged",
+		"log_dry_run_enabled":     "Dry-run mode enabled: operations are recorded but not executed",
+		"log_dry_run_wake":        "[DRY-RUN] Wake timer would be armed for %s",
+		"log_dry_run_kill":        "[DRY-RUN] Blocklisted process %s would be terminated",
+		"log_dry_run_hook":        "[DRY-RUN] %s hook %s would run: %s",
+		"log_cron_fired":          "Cron trigger %s (%s) fired, executing %s",
+		"log_cron_missed":         "Cron trigger %s missed its run at %s, skipping",
+		"log_quota_save_failed":   "Failed to save screen-time quota: %v",
+		"log_quota_exhausting":    "Daily screen time is running out, operation scheduled at %s (%s)",
+		"log_quota_exhausted":     "Daily screen time used up, executing %s",
+		"log_idle_fired":          "Idle policy %s: user idle for %d minutes, executing %s",
+		"log_queue_fired":         "Scheduled operation #%d due, executing %s",
+		"log_queue_missed":        "Scheduled operation #%d (%s at %s) was missed and has been dropped",
+		"log_queue_save_failed":   "Failed to save the queue: %v",
+		"log_extension_granted":   "Extension #%d granted (%d of %d tonight)",
+		"log_extension_pending":   "Extension #%d requested beyond the %d allowed tonight, waiting for a parent to approve it",
+		"log_extension_applied":   "Extension #%d applied, %s moved to %s",
+		"log_extension_failed":    "Failed to save the extension record: %v",
+		"log_extension_refused":   "Extension #%d: %s refused, wrong approval PIN",
+		"log_approval_refused":    "Remote command %s refused, wrong approval PIN",
+		"log_lockout_attempt":     "Powered on inside window %s, which was already enforced at %s (attempt %d), executing %s at %s",
+		"log_lockout_failed":      "Failed to save the lockout record: %v",
+		"log_wake_armed":          "Wake timer armed for %s",
+		"log_wake_cleared":        "Wake timer cleared, no wake time configured",
+		"log_wake_failed":         "Failed to set the wake timer: %v",
+		"log_hook_started":        "Running %s hook %s",
+		"log_hook_output":         "[hook %s] %s",
+		"log_hook_finished":       "Hook %s finished",
+		"log_hook_failed":         "Hook %s failed: %v (on failure: %s)",
+		"log_hook_aborted":        "%s cancelled by a failed hook",
+		"log_escalation_failed":   "%s failed: %v, trying %s",
+		"log_escalation_timeout":  "%s did not take effect within %s, trying %s",
+		"log_escalation_end":      "%s did not take effect, no further escalation step",
+		"log_history_failed":      "Failed to save the operation history: %v",
+		"log_inhibited":           "%s is held back by %s",
+		"log_tamper_detected":     "System clock changed by %s (now %s, trusted time %s), response: %s",
+		"log_state_restored":      "Restored scheduler state, operation scheduled at %s",
+		"log_state_save_failed":   "Failed to save scheduler state: %v",
+		"log_state_load_failed":   "Failed to load scheduler state, starting fresh: %v",
+		"log_process_deferred":    "Allowlisted processes running (%[2]s), deferring %[1]s (%[3]s)",
+		"log_process_defer_limit": "Allowlisted processes still running (%[2]s), but %[1]s was already deferred %[3]d min, executing now",
+		"log_process_killed":      "Terminated blocklisted process %s",
+		"log_process_kill_failed": "Failed to terminate blocklisted process %s: %v",
+		"log_busy_postponed":      "System busy (%[2]s; over limit: %[3]s), postponing %[1]s by %[4]d min",
+		"log_busy_limit":          "System still busy (%[2]s), but %[1]s was already postponed %[3]d min, executing now",
+		"log_busy_no_data":        "Cannot measure system load for %s: %v, not postponing",
+		"log_busy_proceed":        "System load normal (%[2]s), executing %[1]s",
+	},
+	"zh-Hans": {
+		// 通用
+		"app_name":     "自动关机",
+		"developed_by": "由 Hu Gang 开发",
+		"version_info": "%s 版本: %s (%s)",
+
+		// 操作模式
+		"mode_shutdown":               "关机",
+		"mode_hibernate":              "休眠",
+		"mode_reboot":                 "重启",
+		"mode_logoff":                 "注销",
+		"mode_suspend":                "睡眠",
+		"mode_hybrid_sleep":           "混合睡眠",
+		"mode_suspend_then_hibernate": "睡眠后休眠",
+		"mode_lock_session":           "锁定会话",
+		"mode_display_off":            "关闭显示器",
+		"mode_force_shutdown":         "强制关机",
+		"mode_force_reboot":           "强制重启",
+
+		// 状态消息
+		"current_status":          "操作模式: %s | 版本: %s",
+		"window_status":           "时间窗口 %s: %s %s - %s | %s",
+		"time_zone_status":        "时区: %s（当前 %s）",
+		"cron_status":             "定时任务 %s: %s | 下次 %s | %s",
+		"idle_policy_status":      "空闲策略 %s: %s %s-%s 空闲 %d 分钟后 | %s",
+		"idle_time_status":        "用户已空闲 %s",
+		"queue_disabled":            "一次性计划操作不可用",
+		"queue_usage":               "用法: schedule in <45m|1h30m> [mode] | schedule at <HH:MM> [mode] | schedule list | schedule cancel <id>",
+		"queue_empty":               "没有计划的操作",
+		"queue_header":              "计划的操作:",
+		"queue_item":                "  #%d %s 于 %s（%s 后）",
+		"queue_added":               "已计划 #%d: %s 于 %s",
+		"queue_cancelled":           "已取消计划的操作 #%d",
+		"queue_not_found":           "没有计划的操作 #%d",
+		"queue_save_failed":         "保存计划队列失败: %v",
+		"extension_disabled":        "没有配置延长策略",
+		"extension_usage":           "用法: extend [list] | extend request | extend approve <id> <pin> | extend deny <id> <pin>",
+		"extension_status":          "延长: 每晚 %d 次，每次 %d 分钟，今晚已用 %d 次",
+		"extension_item":            "  #%d %s %s +%d 分钟: %s",
+		"extension_status_granted":  "已允许",
+		"extension_status_pending":  "等待批准",
+		"extension_status_approved": "已批准",
+		"extension_status_denied":   "已拒绝",
+		"extension_status_expired":  "已过期，未及时处理",
+		"extension_no_window":       "当前不在时间窗口内，没有可以延长的操作",
+		"extension_granted":         "延长 #%d 已允许: 操作推迟 %d 分钟",
+		"extension_pending":         "延长 #%d 需要家长批准（extend approve %d <pin>）",
+		"extension_approved":        "延长 #%d 已批准: 操作推迟 %d 分钟",
+		"extension_denied":          "延长 #%[1]d 已拒绝",
+		"extension_not_pending":     "没有等待批准的延长申请 #%d",
+		"extension_no_pin":          "需要在 extensions 配置中设置 approval_pin 才能批准或拒绝申请",
+		"extension_wrong_pin":       "批准密码错误",
+		"approval_no_pin":           "需要在 extensions 配置中设置 approval_pin 才能使用 %s",
+		"extension_save_failed":     "保存延长记录失败: %v",
+		"extension_question":        "是否申请延长 %d 分钟？",
+		"extension_remote_hint":     "如需延长，请发送远程命令: extend request",
+		"lockout_disabled":          "锁定功能未启用",
+		"lockout_status":            "锁定: 已执行过操作的窗口内再次开机，%s 后再次执行操作",
+		"lockout_none":              "还没有窗口被锁定",
+		"lockout_record":            "窗口 %s（%s）已于 %s 执行操作，之后再次开机 %d 次",
+		"lockout_attempt":           "  %s 再次开机",
+		"wake_unavailable":          "定时唤醒不可用",
+		"wake_usage":                "用法: wake [status] | wake at <HH:MM> [days] | wake off [days]，days 例如 mon-fri 或 sat,sun",
+		"wake_none":                 "没有设置唤醒时间",
+		"wake_status":               "唤醒 %s %s",
+		"wake_next":                 "下次唤醒: %s",
+		"wake_armed":                "唤醒计时器已设置为 %s",
+		"history_none":              "还没有操作记录",
+		"history_entry":             "#%d %s  %s（%s）",
+		"history_step":              "  %s %s: %s",
+		"history_step_error":        "  %s %s: %s（%s）",
+		"history_started":           "已执行",
+		"history_confirmed":         "已确认",
+		"history_failed":            "失败",
+		"history_unconfirmed":       "未在时限内确认",
+		"history_stopped":           "服务已停止",
+		"history_blocker":           "    被 %s 阻止",
+		"simulate_usage":            "用法: simulate [YYYY-MM-DD] [days]，最多 %d 天",
+		"simulate_header":           "模拟计划 %s - %s（%s），假设每个窗口开始时电脑都开着:",
+		"simulate_none":             "这段时间内不会进入任何窗口",
+		"simulate_entry":            "%s  窗口 %s（%s-%s）: %s",
+		"simulate_warning":          "    警告 %s",
+		"simulate_operation":        "    操作 %s",
+		"simulate_maybe":            "    操作 %s，延迟超过窗口结束时跳过",
+		"simulate_skipped":          "    不执行操作，窗口在延迟结束前结束",
+		"wake_not_armed":            "唤醒计时器未设置",
+		"wake_armed_unknown":        "无法读取唤醒计时器: %v",
+		"wake_set":                  "唤醒时间已设置为 %s（%s）",
+		"wake_removed":              "已删除 %s 的唤醒时间",
+		"tamper_disabled":           "未启用系统时间篡改检测",
+		"tamper_status":             "系统时间篡改检测: 处理方式 %s，阈值 %s，可信时间与系统时间相差 %s",
+		"tamper_event":              "  %s",
+		"tamper_accepted":           "已将当前系统时间作为可信时间",
+		"tamper_usage":              "用法: tamper [status] | tamper accept <pin>",
+		"processes_disabled":        "未配置进程列表",
+		"processes_list_failed":     "获取进程列表失败: %v",
+		"processes_allow":           "白名单进程（最多推迟 %[2]d 分钟）: %[1]s",
+		"processes_deferring":       "正在推迟操作的进程: %s",
+		"processes_not_deferring":   "没有正在运行的白名单进程",
+		"processes_block":           "黑名单进程（在时间窗口内会被结束）: %s",
+		"processes_blocked_running": "正在运行的黑名单进程: %s",
+		"busy_status":             "繁忙保护: %s 时推迟，最多 %d 分钟",
+		"busy_decision":           "  %s %s (%s): %s | %s",
+		"busy_verdict_postponed":  "已推迟",
+		"busy_verdict_proceed":    "已执行",
+		"busy_verdict_limit":      "已执行，达到最长推迟时间",
+		"busy_verdict_no-data":    "已执行，无法获取负载",
+		"quota_status":            "今日屏幕时间: 已用 %s / %s（追加 %s），剩余 %s",
+		"quota_granted":           "已为今天追加 %d 分钟",
+		"quota_reset":             "今日屏幕时间已重置",
+		"quota_disabled":          "未配置每日屏幕时间配额",
+		"quota_usage":             "用法: quota status | quota grant <分钟> <pin> | quota reset <pin>",
+		"scheduled_shutdown":      "计划在 %s %s",
+		"executing_operation":     "正在执行%s操作...",
+		"operation_successful":    "%s操作成功",
+		"operation_failed":        "%s命令失败: %v",
+		"shutdown_warning":        "警告: 计算机将在%d分钟后%s。请立即保存您的工作！",
+		"shutdown_warning_title":   "系统%s警告",
+		"shutdown_warning_cancel":  "取消",
+		"shutdown_warning_proceed": "继续",
+		"countdown":               "倒计时",
+		"ok_button":               "确定",
+		"shutdown_cancelled":       "%s操作已取消",
+		"unknown_command":         "未知命令，请使用 'help' 查看可用命令",
+		"please_specify_language": "请指定语言代码（en 或 zh-Hans）",
+		"invalid_time_type":       "时间类型无效。请使用 'start' 或 'end'",
+		"system_status":           "系统状态: 正常运行\n当前时间: %s\n时间范围: %02d:%02d - %02d:%02d\n当前操作模式: %s",
+		"dry_run_status":          "演练模式: 已记录 %d 次操作",
+		"dry_run_record":          "  %s  %s (%s)",
+
+		// 命令响应
+		"enter_command":       "请输入命令",
+		"enter_start_time":    "请输入开始时间（格式为 HH:MM），例如 22:00",
+		"enter_end_time":      "请输入结束时间（格式为 HH:MM），例如 06:00",
+		"invalid_time_format": "时间格式无效。请使用 HH:MM 格式，或带偏移的 sunrise/sunset，例如 sunset+30m。",
+		"time_set_success":    "%s时间设置为 %02d:%02d",
+		"window_time_set_success": "时间窗口 %s: %s时间设置为 %s",
+		"solar_needs_coordinates": "日出日落时间需要在配置文件中设置经纬度，或使用 -latitude/-longitude",
+		"window_solar_today":      "（今天 %s-%s）",
+		"window_not_found":    "未知的时间窗口: %s",
+		"upcoming_exceptions": "未来 %d 天的日历例外:",
+		"no_exceptions":       "未来 %d 天没有日历例外",
+		"exception_item":      "  %s - %s  %s: %s",
+		"exception_action_skip":    "不执行",
+		"exception_action_weekend": "按周末规则",
+		"exception_action_window":  "自定义时间窗口",
+		"time_start":          "开始",
+		"time_end":            "结束",
+		"invalid_mode":        "无效的模式。可用模式: shutdown(关机), hibernate(休眠), reboot(重启), logoff(注销), suspend(睡眠), hybrid-sleep(混合睡眠), suspend-then-hibernate(睡眠后休眠), lock-session(锁定会话), display-off(关闭显示器)",
+		"mode_set_success":    "操作模式设置为: %s",
+
+		// 菜单
+		"welcome_title":       "===== 自动关机远程控制 =====",
+		"menu_item":              "%d. %s",
+		"menu_shutdown":          "关闭计算机",
+		"menu_hibernate":         "休眠计算机",
+		"menu_reboot":            "重启计算机",
+		"menu_logoff":            "注销当前用户",
+		"menu_suspend":           "睡眠计算机",
+		"menu_hybrid_sleep":      "混合睡眠",
+		"menu_suspend_hibernate": "睡眠后休眠",
+		"menu_lock_session":      "锁定会话",
+		"menu_display_off":       "关闭显示器",
+		"menu_status":            "查看系统状态",
+		"menu_set_start_time":    "设置开始时间",
+		"menu_set_end_time":      "设置结束时间",
+		"menu_set_mode":          "设置操作模式",
+		"menu_language":          "更改语言",
+		"menu_help":              "显示帮助",
+		"menu_exit":              "退出",
+		"menu_prompt":            "请输入选项编号: ",
+
+		// 帮助文本
+		"help_text": `可用命令:
+- shutdown: 关闭计算机
+- hibernate: 休眠计算机
+- reboot: 重启计算机
+- logoff: 注销当前用户
+- suspend: 睡眠计算机
+- hybrid-sleep: 混合睡眠（同时保存到内存和磁盘）
+- suspend-then-hibernate: 睡眠，之后转为休眠
+- lock-session: 锁定当前会话
+- display-off: 关闭显示器
+- setmode [mode]: 设置操作模式（上面的任一模式）
+- status: 查看系统状态
+- exceptions [days]: 列出即将到来的日历例外（默认 30 天）
+- quota status|grant <minutes>|reset: 查看、追加或重置今日屏幕时间
+- processes: 查看正在推迟操作的进程和被禁止的进程
+- schedule in <45m> [mode]: 延迟一段时间后执行一次操作
+- schedule at <HH:MM> [mode]: 在下一个 HH:MM 执行一次操作
+- schedule list|cancel <id>: 列出或取消一次性操作
+- extend [list]: 显示延长策略和最近的申请
+- extend request: 在计划的操作之前申请延长时间
+- extend approve|deny <id> <pin>: 批准或拒绝超过今晚次数的延长申请
+- tamper [status|accept]: 查看检测到的系统时间修改，或接受当前时间
+- lockout: 查看已锁定的窗口和之后的开机次数
+- history: 查看最近的操作及其到达的升级步骤
+- simulate [YYYY-MM-DD] [days]: 查看接下来几天的计划会做什么（默认 7 天）
+- wake [status]: 查看唤醒时间和已设置的唤醒计时器
+- wake at <HH:MM> [days]: 在 HH:MM 唤醒电脑（每天，或如 mon-fri）
+- wake off [days]: 删除唤醒时间
+- settime [window] start HH:MM: 设置开始时间（未指定窗口时修改第一个窗口），也可以是 sunset+30m
+- settime [window] end HH:MM: 设置结束时间（未指定窗口时修改第一个窗口），也可以是 sunrise-15m
+- language [code]: 更改语言 (en/zh-Hans)
+- version: 显示版本信息
+- help: 显示帮助信息`,
+
+		// 语言
+		"language_changed":      "语言已更改为: %s",
+		"language_name_en":      "英文",
+		"language_name_zh-Hans": "简体中文",
+		"invalid_language":      "无效的语言代码。可用语言: en, zh-Hans",
+
+		// 日志消息
+		"log_tcp_server_started":  "TCP远程控制服务器已启动，监听端口 %s",
+		"log_udp_server_started":  "UDP远程控制服务器已启动，监听端口 %s",
+		"log_accept_failed":       "接受连接失败: %v",
+		"log_new_tcp_connection":  "新的TCP连接来自: %s",
+		"log_command_read_failed": "读取命令失败: %v",
+		"log_udp_addr_failed":     "解析UDP地址失败: %v",
+		"log_udp_listen_failed":   "UDP服务器启动失败: %v",
+		"log_udp_read_failed":     "读取UDP数据失败: %v",
+		"log_udp_command":         "收到来自 %s 的UDP命令: %s",
+		"log_service_installed":   "服务安装成功",
+		"log_service_removed":     "服务卸载成功",
+		"log_service_stopped":     "服务停止成功",
+		"log_service_started":     "服务启动成功",
+		"log_dry_run_operation":   "[演练] 已记录%s操作（原因: %s），未改变电源状态",
+		"log_dry_run_enabled":     "演练模式已启用: 操作只记录不执行",
+		"log_dry_run_wake":        "[演练] 将设置唤醒时间 %s，未改动硬件定时器",
+		"log_dry_run_kill":        "[演练] 将结束黑名单进程 %s，未实际结束",
+		"log_dry_run_hook":        "[演练] 将运行 %s 钩子 %s: %s",
+		"log_cron_fired":          "定时任务 %s (%s) 已触发，执行%s操作",
+		"log_cron_missed":         "定时任务 %s 错过了 %s 的执行，已跳过",
+		"log_quota_save_failed":   "保存屏幕时间配额失败: %v",
+		"log_quota_exhausting":    "今日屏幕时间即将用完，计划在 %s 执行操作（%s）",
+		"log_quota_exhausted":     "今日屏幕时间已用完，执行%s操作",
+		"log_idle_fired":          "空闲策略 %s: 用户已空闲 %d 分钟，执行%s操作",
+		"log_queue_fired":         "计划的操作 #%d 已到时间，执行%s操作",
+		"log_queue_missed":        "计划的操作 #%d（%s，%s）已错过，已删除",
+		"log_queue_save_failed":   "保存计划队列失败: %v",
+		"log_extension_granted":   "延长 #%d 已允许（今晚第 %d 次，共 %d 次）",
+		"log_extension_pending":   "延长 #%d 超过今晚允许的 %d 次，等待家长批准",
+		"log_extension_applied":   "延长 #%d 已生效，%s操作推迟到 %s",
+		"log_extension_failed":    "保存延长记录失败: %v",
+		"log_extension_refused":   "延长 #%d: %s 被拒绝，批准密码错误",
+		"log_approval_refused":    "远程命令 %s 被拒绝，批准密码错误",
+		"log_lockout_attempt":     "在已于 %[2]s 执行过操作的窗口 %[1]s 内再次开机（第 %[3]d 次），将于 %[5]s 执行%[4]s操作",
+		"log_lockout_failed":      "保存锁定记录失败: %v",
+		"log_wake_armed":          "唤醒计时器已设置为 %s",
+		"log_wake_cleared":        "没有唤醒时间，已清除唤醒计时器",
+		"log_wake_failed":         "设置唤醒计时器失败: %v",
+		"log_hook_started":        "运行 %s 钩子 %s",
+		"log_hook_output":         "[钩子 %s] %s",
+		"log_hook_finished":       "钩子 %s 已完成",
+		"log_hook_failed":         "钩子 %s 失败: %v（失败策略: %s）",
+		"log_hook_aborted":        "钩子失败，已取消%s操作",
+		"log_escalation_failed":   "%s失败: %v，尝试%s",
+		"log_escalation_timeout":  "%s在 %s 内没有生效，尝试%s",
+		"log_escalation_end":      "%s没有生效，没有更多的升级步骤",
+		"log_history_failed":      "保存操作历史失败: %v",
+		"log_inhibited":           "%s被 %s 阻止",
+		"log_tamper_detected":     "系统时间被修改了 %s（现在 %s，可信时间 %s），处理方式: %s",
+		"log_state_restored":      "已恢复调度状态，计划在 %s 执行操作",
+		"log_state_save_failed":   "保存调度状态失败: %v",
+		"log_state_load_failed":   "读取调度状态失败，重新开始: %v",
+		"log_process_deferred":    "白名单进程正在运行（%[2]s），推迟%[1]s操作（%[3]s）",
+		"log_process_defer_limit": "白名单进程仍在运行（%[2]s），但%[1]s操作已推迟 %[3]d 分钟，立即执行",
+		"log_process_killed":      "已结束黑名单进程 %s",
+		"log_process_kill_failed": "结束黑名单进程 %s 失败: %v",
+		"log_busy_postponed":      "系统繁忙（%[2]s；超出阈值: %[3]s），%[1]s操作推迟 %[4]d 分钟",
+		"log_busy_limit":          "系统仍然繁忙（%[2]s），但%[1]s操作已推迟 %[3]d 分钟，立即执行",
+		"log_busy_no_data":        "无法获取系统负载，%s操作不推迟: %v",
+		"log_busy_proceed":        "系统负载正常（%[2]s），执行%[1]s操作",
+	},
+}
+
+// Current language
+var currentLang = "en"
+
+// Get localized string
+func T(key string, args ...interface{}) string {
+	if str, ok := langStrings[currentLang][key]; ok {
+		if len(args) > 0 {
+			return fmt.Sprintf(str, args...)
+		}
+		return str
+	}
+
+	// Fallback to English
+	if str, ok := langStrings["en"][key]; ok {
+		if len(args) > 0 {
+			return fmt.Sprintf(str, args...)
+		}
+		return str
+	}
+
+	// Return key if not found
+	return key
+}
+
+// Change language
+func SetLanguage(lang string) bool {
+	if _, ok := langStrings[lang]; ok {
+		currentLang = lang
+		return true
+	}
+	return false
+}
+
+// Get language name
+func GetLanguageName(lang string) string {
+	key := "language_name_" + lang
+	return T(key)
+}
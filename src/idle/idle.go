@@ -0,0 +1,39 @@
+// Package idle reports how long the user has not touched keyboard or mouse
+package idle
+
+import (
+	"sync"
+	"time"
+)
+
+// Detector returns the time since the last user input
+type Detector interface {
+	IdleTime() (time.Duration, error)
+}
+
+// Fake is a Detector for tests; the idle time is set by hand
+type Fake struct {
+	mu   sync.Mutex
+	idle time.Duration
+	err  error
+}
+
+// Set changes the reported idle time
+func (f *Fake) Set(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.idle = d
+}
+
+// Fail makes IdleTime return err, nil clears it
+func (f *Fake) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *Fake) IdleTime() (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.idle, f.err
+}
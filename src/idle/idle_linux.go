@@ -0,0 +1,123 @@
+//go:build linux
+// +build linux
+
+package idle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/logind"
+)
+
+// rescanInterval is how often /dev/input is searched for new devices (e.g. a USB keyboard)
+const rescanInterval = time.Minute
+
+// linuxDetector watches /dev/input/event* for any activity. If no device can
+// be opened (not running as root, or no local input at all) it falls back to
+// the IdleHint that the desktop environment reports to systemd-logind.
+type linuxDetector struct {
+	logind *logind.Client
+	dir    string
+
+	mu        sync.Mutex
+	watched   map[string]bool
+	lastInput time.Time
+	lastScan  time.Time
+}
+
+// NewDetector returns the idle detector for this platform
+func NewDetector() Detector {
+	return newLinuxDetector(logind.NewSystemBus(), "/dev/input")
+}
+
+func newLinuxDetector(bus logind.Bus, dir string) *linuxDetector {
+	return &linuxDetector{
+		logind:    logind.NewClient(bus),
+		dir:       dir,
+		watched:   map[string]bool{},
+		lastInput: time.Now(),
+	}
+}
+
+func (d *linuxDetector) IdleTime() (time.Duration, error) {
+	d.scan()
+
+	d.mu.Lock()
+	devices := len(d.watched)
+	lastInput := d.lastInput
+	d.mu.Unlock()
+
+	if devices > 0 {
+		return time.Since(lastInput), nil
+	}
+
+	_, session, err := d.logind.ActiveSession()
+	if err != nil {
+		return 0, err
+	}
+	idle, since, err := d.logind.SessionIdle(session)
+	if err != nil {
+		return 0, err
+	}
+	if !idle {
+		return 0, nil
+	}
+	if since.IsZero() {
+		return 0, fmt.Errorf("logind reports the session idle without IdleSinceHint")
+	}
+	return time.Since(since), nil
+}
+
+// scan opens input devices that are not watched yet
+func (d *linuxDetector) scan() {
+	d.mu.Lock()
+	if !d.lastScan.IsZero() && time.Since(d.lastScan) < rescanInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastScan = time.Now()
+	d.mu.Unlock()
+
+	paths, _ := filepath.Glob(filepath.Join(d.dir, "event*"))
+	for _, path := range paths {
+		d.mu.Lock()
+		watched := d.watched[path]
+		d.mu.Unlock()
+		if watched {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			applog.Debugf("无法打开输入设备 %s: %v", path, err)
+			continue
+		}
+		d.mu.Lock()
+		d.watched[path] = true
+		d.mu.Unlock()
+		go d.watch(path, f)
+	}
+}
+
+// watch records the time of every event read from an input device until it goes away
+func (d *linuxDetector) watch(path string, f *os.File) {
+	defer f.Close()
+	buf := make([]byte, 64)
+	for {
+		if _, err := f.Read(buf); err != nil {
+			applog.Debugf("输入设备 %s 已关闭: %v", path, err)
+			d.mu.Lock()
+			delete(d.watched, path)
+			d.mu.Unlock()
+			return
+		}
+		d.mu.Lock()
+		d.lastInput = time.Now()
+		d.mu.Unlock()
+	}
+}
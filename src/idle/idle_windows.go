@@ -0,0 +1,45 @@
+//go:build windows
+// +build windows
+
+package idle
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procGetLastInputInfo = user32.NewProc("GetLastInputInfo")
+	procGetTickCount     = kernel32.NewProc("GetTickCount")
+)
+
+// lastInputInfo mirrors the LASTINPUTINFO structure
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// windowsDetector uses GetLastInputInfo. The call only sees input of the
+// session the process runs in, so the service must run in the user's session
+// (or be started with the user's desktop) for the idle time to be meaningful.
+type windowsDetector struct{}
+
+// NewDetector returns the idle detector for this platform
+func NewDetector() Detector {
+	return windowsDetector{}
+}
+
+func (windowsDetector) IdleTime() (time.Duration, error) {
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	ret, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return 0, fmt.Errorf("GetLastInputInfo: %v", err)
+	}
+	now, _, _ := procGetTickCount.Call()
+	// 两个值都是开机后的毫秒数，32位回绕时减法依然正确
+	return time.Duration(uint32(now)-info.dwTime) * time.Millisecond, nil
+}
@@ -0,0 +1,145 @@
+// Package lockout keeps a window locked once its operation has run, so powering the
+// machine back on inside the same window does not earn a new random delay
+package lockout
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/fsutil"
+)
+
+// MaxRecords is the number of enforced windows kept, older ones are dropped
+const MaxRecords = 30
+
+// Config is the "lockout" section of the config file
+type Config struct {
+	Enabled      bool `json:"enabled"`
+	GraceSeconds int  `json:"grace_seconds,omitempty"` // Time before the operation repeats, 0 repeats it at once
+}
+
+// Grace returns the fixed delay before the operation repeats
+func (c Config) Grace() time.Duration {
+	return time.Duration(c.GraceSeconds) * time.Second
+}
+
+// Validate checks the grace period
+func (c Config) Validate() error {
+	if c.GraceSeconds < 0 {
+		return fmt.Errorf("lockout: grace_seconds must not be negative")
+	}
+	return nil
+}
+
+// Record is one window occurrence in which the operation was enforced
+type Record struct {
+	Window   string      `json:"window"`
+	Night    string      `json:"night"` // 2006-01-02 on which the window started
+	Enforced time.Time   `json:"enforced"`
+	Attempts []time.Time `json:"attempts,omitempty"` // Boots and resumes inside the window afterwards
+}
+
+// Tracker records the enforced windows and the attempts to use the machine afterwards
+type Tracker struct {
+	mu      sync.Mutex
+	path    string
+	records []Record
+}
+
+// Open loads the records from path. A missing file starts empty.
+func Open(path string) (*Tracker, error) {
+	t := &Tracker{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t.records); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return t, nil
+}
+
+// Enforce records that the operation of window ran on night. Only the first
+// enforcement of a window occurrence is kept.
+func (t *Tracker) Enforce(window, night string, at time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.find(window, night) != nil {
+		return nil
+	}
+	t.records = append(t.records, Record{Window: window, Night: night, Enforced: at})
+	if len(t.records) > MaxRecords {
+		t.records = t.records[len(t.records)-MaxRecords:]
+	}
+	return t.save()
+}
+
+// Enforced returns the record of window on night, if its operation already ran
+func (t *Tracker) Enforced(window, night string) (Record, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := t.find(window, night)
+	if r == nil {
+		return Record{}, false
+	}
+	return r.copy(), true
+}
+
+// Attempt counts a boot or resume inside window on night. It reports false, and
+// counts nothing, if the window has not been enforced yet.
+func (t *Tracker) Attempt(window, night string, at time.Time) (Record, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := t.find(window, night)
+	if r == nil {
+		return Record{}, false, nil
+	}
+	r.Attempts = append(r.Attempts, at)
+	return r.copy(), true, t.save()
+}
+
+// List returns a copy of the records, oldest first
+func (t *Tracker) List() []Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	records := make([]Record, len(t.records))
+	for i, r := range t.records {
+		records[i] = r.copy()
+	}
+	return records
+}
+
+func (t *Tracker) find(window, night string) *Record {
+	for i := range t.records {
+		if t.records[i].Window == window && t.records[i].Night == night {
+			return &t.records[i]
+		}
+	}
+	return nil
+}
+
+func (r Record) copy() Record {
+	r.Attempts = append([]time.Time(nil), r.Attempts...)
+	return r
+}
+
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	records := t.records
+	if records == nil {
+		records = []Record{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(t.path, data, 0644)
+}
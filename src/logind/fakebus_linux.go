@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package logind
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// FakeCall records a single method call made on a FakeBus
+type FakeCall struct {
+	Path   dbus.ObjectPath
+	Method string
+	Args   []interface{}
+}
+
+// FakeBus is a Bus for tests. It records every call and answers from
+// Replies and Errors, keyed by the fully qualified method name. Property
+// reads are answered from Properties, keyed by "interface.property".
+type FakeBus struct {
+	mu         sync.Mutex
+	Calls      []FakeCall
+	Replies    map[string][]interface{}
+	Errors     map[string]error
+	Properties map[string]interface{}
+}
+
+// NewFakeBus returns a FakeBus whose seat0 has an active, non-idle session with the given ID
+func NewFakeBus(activeSession string) *FakeBus {
+	session := []interface{}{activeSession, dbus.ObjectPath("/org/freedesktop/login1/session/" + activeSession)}
+	return &FakeBus{
+		Replies: map[string][]interface{}{},
+		Errors:  map[string]error{},
+		Properties: map[string]interface{}{
+			"org.freedesktop.login1.Seat.ActiveSession":    session,
+			"org.freedesktop.login1.Session.IdleHint":      false,
+			"org.freedesktop.login1.Session.IdleSinceHint": uint64(0),
+		},
+	}
+}
+
+// SetIdle marks the fake session idle since the given time, or active if since is zero
+func (b *FakeBus) SetIdle(since time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Properties["org.freedesktop.login1.Session.IdleHint"] = !since.IsZero()
+	b.Properties["org.freedesktop.login1.Session.IdleSinceHint"] = uint64(since.UnixNano() / int64(time.Microsecond))
+}
+
+func (b *FakeBus) Call(path dbus.ObjectPath, method string, args ...interface{}) ([]interface{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Calls = append(b.Calls, FakeCall{Path: path, Method: method, Args: args})
+	if err := b.Errors[method]; err != nil {
+		return nil, err
+	}
+	if method == "org.freedesktop.DBus.Properties.Get" && len(args) == 2 {
+		key := fmt.Sprintf("%v.%v", args[0], args[1])
+		value, ok := b.Properties[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown property %s", key)
+		}
+		return []interface{}{dbus.MakeVariant(value)}, nil
+	}
+	return b.Replies[method], nil
+}
+
+// Methods returns the names of the methods called so far, in order
+func (b *FakeBus) Methods() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	methods := make([]string, 0, len(b.Calls))
+	for _, c := range b.Calls {
+		methods = append(methods, c.Method)
+	}
+	return methods
+}
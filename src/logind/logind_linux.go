@@ -0,0 +1,243 @@
+//go:build linux
+// +build linux
+
+// Package logind is a small client for the systemd-logind D-Bus API
+package logind
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// rootCheckInhibitors is SD_LOGIND_ROOT_CHECK_INHIBITORS: without it logind lets root
+	// shut down or sleep regardless of the inhibitors applications hold
+	rootCheckInhibitors = uint64(1 << 0)
+	// unknownMethod is returned by logind versions before systemd 248, which lack the *WithFlags methods
+	unknownMethod = "org.freedesktop.DBus.Error.UnknownMethod"
+
+	logindService = "org.freedesktop.login1"
+	logindPath    = dbus.ObjectPath("/org/freedesktop/login1")
+	logindManager = "org.freedesktop.login1.Manager"
+	logindSeat0   = dbus.ObjectPath("/org/freedesktop/login1/seat/seat0")
+)
+
+// Bus is the part of the D-Bus system bus used to reach systemd-logind.
+// Call invokes method (fully qualified, e.g. "org.freedesktop.login1.Manager.PowerOff")
+// on the logind object at path and returns the reply body.
+type Bus interface {
+	Call(path dbus.ObjectPath, method string, args ...interface{}) ([]interface{}, error)
+}
+
+// systemBus connects to the real system bus on first use
+type systemBus struct {
+	mu   sync.Mutex
+	conn *dbus.Conn
+}
+
+// NewSystemBus returns a Bus on the system bus; the connection is made on the first call
+func NewSystemBus() Bus {
+	return &systemBus{}
+}
+
+func (b *systemBus) Call(path dbus.ObjectPath, method string, args ...interface{}) ([]interface{}, error) {
+	b.mu.Lock()
+	if b.conn == nil {
+		conn, err := dbus.SystemBus()
+		if err != nil {
+			b.mu.Unlock()
+			return nil, fmt.Errorf("connect to system bus: %v", err)
+		}
+		b.conn = conn
+	}
+	conn := b.conn
+	b.mu.Unlock()
+
+	call := conn.Object(logindService, path).Call(method, 0, args...)
+	return call.Body, call.Err
+}
+
+// Client wraps the systemd-logind methods used by AutoShutdown
+type Client struct {
+	bus Bus
+}
+
+// NewClient returns a Client that talks to logind over bus
+func NewClient(bus Bus) *Client {
+	return &Client{bus: bus}
+}
+
+// PowerOff shuts the machine down
+func (l *Client) PowerOff() error {
+	return l.power("PowerOff")
+}
+
+// Reboot restarts the machine
+func (l *Client) Reboot() error {
+	return l.power("Reboot")
+}
+
+// Hibernate suspends the machine to disk
+func (l *Client) Hibernate() error {
+	return l.power("Hibernate")
+}
+
+// Suspend suspends the machine to RAM
+func (l *Client) Suspend() error {
+	return l.power("Suspend")
+}
+
+// HybridSleep suspends the machine to RAM and disk
+func (l *Client) HybridSleep() error {
+	return l.power("HybridSleep")
+}
+
+// SuspendThenHibernate suspends the machine and hibernates it after the configured delay
+func (l *Client) SuspendThenHibernate() error {
+	return l.power("SuspendThenHibernate")
+}
+
+// LockSession asks the session with the given ID to lock its screen
+func (l *Client) LockSession(id string) error {
+	return l.manager("LockSession", id)
+}
+
+// TerminateSession ends the session with the given ID, logging its user off
+func (l *Client) TerminateSession(id string) error {
+	return l.manager("TerminateSession", id)
+}
+
+// Inhibitor is a lock an application holds to delay or block shutdown, sleep or idle
+type Inhibitor struct {
+	What string // Colon separated, e.g. "shutdown:sleep"
+	Who  string // Application name
+	Why  string
+	Mode string // "block" or "delay"
+	UID  uint32
+	PID  uint32
+}
+
+// ListInhibitors returns the inhibitor locks currently held
+func (l *Client) ListInhibitors() ([]Inhibitor, error) {
+	body, err := l.bus.Call(logindPath, logindManager+".ListInhibitors")
+	if err != nil {
+		return nil, fmt.Errorf("logind ListInhibitors: %v", err)
+	}
+	if len(body) != 1 {
+		return nil, fmt.Errorf("logind ListInhibitors: unexpected reply")
+	}
+	// 回复的签名是 a(ssssuu)，godbus 把每个结构解码为 []interface{}
+	list, ok := body[0].([][]interface{})
+	if !ok {
+		return nil, fmt.Errorf("logind ListInhibitors: unexpected reply")
+	}
+	inhibitors := make([]Inhibitor, 0, len(list))
+	for _, fields := range list {
+		if len(fields) != 6 {
+			continue
+		}
+		var i Inhibitor
+		i.What, _ = fields[0].(string)
+		i.Who, _ = fields[1].(string)
+		i.Why, _ = fields[2].(string)
+		i.Mode, _ = fields[3].(string)
+		i.UID, _ = fields[4].(uint32)
+		i.PID, _ = fields[5].(uint32)
+		inhibitors = append(inhibitors, i)
+	}
+	return inhibitors, nil
+}
+
+// ActiveSession returns the ID and object path of the session in the foreground on seat0
+func (l *Client) ActiveSession() (string, dbus.ObjectPath, error) {
+	v, err := l.property(logindSeat0, "org.freedesktop.login1.Seat", "ActiveSession")
+	if err != nil {
+		return "", "", fmt.Errorf("logind ActiveSession: %v", err)
+	}
+	// ActiveSession has the signature (so): session ID and object path
+	if fields, ok := v.Value().([]interface{}); ok && len(fields) == 2 {
+		id, _ := fields[0].(string)
+		path, _ := fields[1].(dbus.ObjectPath)
+		if id != "" {
+			return id, path, nil
+		}
+	}
+	return "", "", fmt.Errorf("logind ActiveSession: no active session on seat0")
+}
+
+// SessionDisplay returns the X11 display of the session, empty for Wayland and text
+// sessions, and the name of its user
+func (l *Client) SessionDisplay(session dbus.ObjectPath) (string, string, error) {
+	display, err := l.property(session, "org.freedesktop.login1.Session", "Display")
+	if err != nil {
+		return "", "", fmt.Errorf("logind Display: %v", err)
+	}
+	name, err := l.property(session, "org.freedesktop.login1.Session", "Name")
+	if err != nil {
+		return "", "", fmt.Errorf("logind Name: %v", err)
+	}
+	d, _ := display.Value().(string)
+	n, _ := name.Value().(string)
+	return d, n, nil
+}
+
+// SessionIdle returns the session's IdleHint and the time it became idle (IdleSinceHint)
+func (l *Client) SessionIdle(session dbus.ObjectPath) (bool, time.Time, error) {
+	hint, err := l.property(session, "org.freedesktop.login1.Session", "IdleHint")
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("logind IdleHint: %v", err)
+	}
+	idle, _ := hint.Value().(bool)
+	if !idle {
+		return false, time.Time{}, nil
+	}
+
+	since, err := l.property(session, "org.freedesktop.login1.Session", "IdleSinceHint")
+	if err != nil {
+		return true, time.Time{}, fmt.Errorf("logind IdleSinceHint: %v", err)
+	}
+	usec, _ := since.Value().(uint64)
+	return true, time.Unix(0, int64(usec)*int64(time.Microsecond)), nil
+}
+
+// property reads a D-Bus property of a logind object
+func (l *Client) property(path dbus.ObjectPath, iface, name string) (dbus.Variant, error) {
+	body, err := l.bus.Call(path, "org.freedesktop.DBus.Properties.Get", iface, name)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	if len(body) != 1 {
+		return dbus.Variant{}, fmt.Errorf("unexpected reply to %s.%s", iface, name)
+	}
+	v, ok := body[0].(dbus.Variant)
+	if !ok {
+		return dbus.Variant{}, fmt.Errorf("unexpected reply to %s.%s", iface, name)
+	}
+	return v, nil
+}
+
+// power calls the *WithFlags variant of method so that the service, which runs as root,
+// respects inhibitors like any user. Older logind versions fall back to the plain
+// method, where root bypasses them.
+func (l *Client) power(method string) error {
+	_, err := l.bus.Call(logindPath, logindManager+"."+method+"WithFlags", rootCheckInhibitors)
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) && dbusErr.Name == unknownMethod {
+		return l.manager(method, false)
+	}
+	if err != nil {
+		return fmt.Errorf("logind %sWithFlags: %v", method, err)
+	}
+	return nil
+}
+
+func (l *Client) manager(method string, args ...interface{}) error {
+	if _, err := l.bus.Call(logindPath, logindManager+"."+method, args...); err != nil {
+		return fmt.Errorf("logind %s: %v", method, err)
+	}
+	return nil
+}
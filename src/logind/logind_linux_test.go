@@ -0,0 +1,75 @@
+//go:build linux
+// +build linux
+
+package logind
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestSessionIdle(t *testing.T) {
+	bus := NewFakeBus("c2")
+	l := NewClient(bus)
+
+	id, path, err := l.ActiveSession()
+	if err != nil || id != "c2" || path != "/org/freedesktop/login1/session/c2" {
+		t.Fatalf("ActiveSession() = %q, %q, %v", id, path, err)
+	}
+
+	if idle, _, err := l.SessionIdle(path); err != nil || idle {
+		t.Errorf("SessionIdle() = %v, %v, want active", idle, err)
+	}
+
+	since := time.Date(2026, 10, 18, 21, 30, 0, 0, time.UTC)
+	bus.SetIdle(since)
+	idle, got, err := l.SessionIdle(path)
+	if err != nil || !idle || !got.Equal(since) {
+		t.Errorf("SessionIdle() = %v, %v, %v, want idle since %v", idle, got, err, since)
+	}
+}
+
+func TestActiveSessionMissing(t *testing.T) {
+	bus := NewFakeBus("")
+	if _, _, err := NewClient(bus).ActiveSession(); err == nil {
+		t.Errorf("ActiveSession() without a session = nil error")
+	}
+}
+
+func TestPowerOffChecksInhibitors(t *testing.T) {
+	bus := NewFakeBus("c2")
+	if err := NewClient(bus).PowerOff(); err != nil {
+		t.Fatalf("PowerOff() = %v", err)
+	}
+	want := []FakeCall{{Path: logindPath, Method: logindManager + ".PowerOffWithFlags", Args: []interface{}{rootCheckInhibitors}}}
+	if !reflect.DeepEqual(bus.Calls, want) {
+		t.Errorf("calls = %+v, want %+v", bus.Calls, want)
+	}
+}
+
+func TestPowerWithoutFlags(t *testing.T) {
+	// systemd 248 之前没有 *WithFlags 方法，退回到原来的方法
+	bus := NewFakeBus("c2")
+	bus.Errors[logindManager+".RebootWithFlags"] = dbus.Error{Name: unknownMethod}
+	if err := NewClient(bus).Reboot(); err != nil {
+		t.Fatalf("Reboot() = %v", err)
+	}
+	want := []string{logindManager + ".RebootWithFlags", logindManager + ".Reboot"}
+	if got := bus.Methods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("methods = %v, want %v", got, want)
+	}
+
+	// 其他错误不重试，例如被抑制锁阻止
+	bus = NewFakeBus("c2")
+	bus.Errors[logindManager+".SuspendWithFlags"] = errors.New("Operation inhibited")
+	if err := NewClient(bus).Suspend(); err == nil {
+		t.Error("Suspend() = nil, want the inhibitor error")
+	}
+	if got := bus.Methods(); len(got) != 1 {
+		t.Errorf("methods = %v, want only SuspendWithFlags", got)
+	}
+}
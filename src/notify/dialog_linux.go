@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package notify
+
+import (
+	"log"
+	"os/exec"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/i18n"
+)
+
+// Show warning notification, return true if the operation should continue.
+// Linux desktops have no blocking dialog we can rely on from a service, so the
+// warning is written to the log, broadcast with wall and sent via notify-send.
+func ShowWarningDialog(mode string, minutes int) bool {
+	title, message := warningText(mode, minutes)
+
+	applog.Debugf("准备显示警告通知")
+	applog.Debugf("标题: %s", title)
+	applog.Debugf("消息: %s", message)
+
+	log.Printf("%s: %s", title, message)
+
+	if err := exec.Command("wall", message).Run(); err != nil {
+		applog.Debugf("wall 执行失败: %v", err)
+	}
+	if err := exec.Command("notify-send", "--urgency=critical", title, message).Run(); err != nil {
+		applog.Debugf("notify-send 执行失败: %v", err)
+	}
+
+	// 通知无法被用户取消
+	return true
+}
+
+// Show warning notification that explains how to ask for extend more minutes.
+// A notification cannot be answered, so this never asks and always returns false.
+// The user sends the "extend request" remote command instead, which also works
+// from the machine itself over 127.0.0.1.
+func ShowExtensionDialog(mode string, minutes, extend int) bool {
+	title, message := extensionText(mode, minutes, extend)
+	message += " " + i18n.T("extension_remote_hint")
+
+	log.Printf("%s: %s", title, message)
+
+	if err := exec.Command("wall", message).Run(); err != nil {
+		applog.Debugf("wall 执行失败: %v", err)
+	}
+	if err := exec.Command("notify-send", "--urgency=critical", title, message).Run(); err != nil {
+		applog.Debugf("notify-send 执行失败: %v", err)
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+//go:build windows
+// +build windows
+
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+
+	"codans.com/autoshut/src/applog"
+)
+
+// Show warning dialog, return true if user confirms to continue
+func ShowWarningDialog(mode string, minutes int) bool {
+	// Create warning message
+	title, message := warningText(mode, minutes)
+
+	if applog.Debug {
+		log.Printf("[DEBUG] 准备显示警告对话框")
+		log.Printf("[DEBUG] 标题: %s", title)
+		log.Printf("[DEBUG] 消息: %s", message)
+	}
+
+	// 使用简单的MessageBox显示警告对话框
+	// 这样可以避免中文字符在PowerShell脚本中的编码问题
+	powershellCmd := fmt.Sprintf(
+		"Add-Type -AssemblyName System.Windows.Forms; $result = [System.Windows.Forms.MessageBox]::Show('%s', '%s', 'OK', 'Warning'); if ($result -eq 'OK') { exit 0 } else { exit 1 }",
+		message, title)
+
+	if applog.Debug {
+		log.Printf("[DEBUG] 使用MessageBox显示警告对话框")
+		log.Printf("[DEBUG] PowerShell命令: %s", powershellCmd)
+	}
+
+	cmd := exec.Command("powershell", "-Command", powershellCmd)
+
+	// 捕获命令输出以便调试
+	if applog.Debug {
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		log.Printf("[DEBUG] 警告对话框命令执行结果: %v", err == nil)
+		if stdout.Len() > 0 {
+			log.Printf("[DEBUG] 命令标准输出: %s", stdout.String())
+		}
+		if stderr.Len() > 0 {
+			log.Printf("[DEBUG] 命令错误输出: %s", stderr.String())
+		}
+		return err == nil
+	} else {
+		err := cmd.Run()
+		return err == nil
+	}
+}
+
+// Show warning dialog with Yes/No buttons, return true if the user asks for extend more minutes
+func ShowExtensionDialog(mode string, minutes, extend int) bool {
+	title, message := extensionText(mode, minutes, extend)
+
+	if applog.Debug {
+		log.Printf("[DEBUG] 准备显示延长确认对话框")
+		log.Printf("[DEBUG] 标题: %s", title)
+		log.Printf("[DEBUG] 消息: %s", message)
+	}
+
+	// Yes 表示申请延长，No 或关闭对话框表示按计划执行
+	powershellCmd := fmt.Sprintf(
+		"Add-Type -AssemblyName System.Windows.Forms; $result = [System.Windows.Forms.MessageBox]::Show('%s', '%s', 'YesNo', 'Warning'); if ($result -eq 'Yes') { exit 0 } else { exit 1 }",
+		message, title)
+
+	err := exec.Command("powershell", "-Command", powershellCmd).Run()
+	applog.Debugf("延长确认对话框结果: %v", err == nil)
+	return err == nil
+}
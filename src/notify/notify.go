@@ -0,0 +1,20 @@
+// Package notify shows the pre-operation warning to the logged-in user
+package notify
+
+import (
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// warningText builds the localized warning title and message
+func warningText(mode string, minutes int) (string, string) {
+	message := i18n.T("shutdown_warning", minutes, power.OperationName(mode))
+	title := i18n.T("shutdown_warning_title", power.OperationName(mode))
+	return title, message
+}
+
+// extensionText builds the warning title and a message that offers extend more minutes
+func extensionText(mode string, minutes, extend int) (string, string) {
+	title, message := warningText(mode, minutes)
+	return title, message + " " + i18n.T("extension_question", extend)
+}
@@ -0,0 +1,17 @@
+package power
+
+// Operation describes a single power state change
+type Operation struct {
+	Mode   string // shutdown, hibernate, reboot, logoff
+	Reason string // Who asked for the operation, e.g. "schedule" or "remote"
+}
+
+// Backend is the PowerBackend implemented by each platform.
+// Perform carries out op and returns an error if the system refused it.
+type Backend interface {
+	Perform(op Operation) error
+}
+
+// SessionHelper is the hidden command with which the Windows service starts itself in
+// the session of the logged-on user, for actions that need the user's desktop
+const SessionHelper = "session-helper"
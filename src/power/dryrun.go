@@ -0,0 +1,54 @@
+package power
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+)
+
+// Record is one operation captured by the dry-run backend
+type Record struct {
+	Time   time.Time
+	Mode   string
+	Reason string
+}
+
+// Recorder is implemented by backends that keep a history of performed operations
+type Recorder interface {
+	Records() []Record
+}
+
+// DryRun is a Backend that records every operation and never changes the power state
+type DryRun struct {
+	mu      sync.Mutex
+	records []Record
+
+	// Now returns the timestamp stored with each record
+	Now func() time.Time
+}
+
+// NewDryRun returns an empty dry-run backend
+func NewDryRun() *DryRun {
+	return &DryRun{Now: time.Now}
+}
+
+func (d *DryRun) Perform(op Operation) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	log.Printf(i18n.T("log_dry_run_operation", OperationName(op.Mode), op.Reason))
+	d.records = append(d.records, Record{Time: d.Now(), Mode: op.Mode, Reason: op.Reason})
+	return nil
+}
+
+// Records returns a copy of the recorded operations, oldest first
+func (d *DryRun) Records() []Record {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	records := make([]Record, len(d.records))
+	copy(records, d.records)
+	return records
+}
@@ -0,0 +1,26 @@
+package power
+
+import "fmt"
+
+// Inhibitor is an application that holds an operation back, e.g. because of unsaved work
+type Inhibitor struct {
+	Who string
+	Why string
+	PID uint32
+}
+
+func (i Inhibitor) String() string {
+	s := i.Who
+	if i.PID != 0 {
+		s += fmt.Sprintf(" (pid %d)", i.PID)
+	}
+	if i.Why != "" {
+		s += ": " + i.Why
+	}
+	return s
+}
+
+// Inhibited is implemented by backends that can tell which applications hold back mode
+type Inhibited interface {
+	Inhibitors(mode string) ([]Inhibitor, error)
+}
@@ -0,0 +1,82 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                       = syscall.NewLazyDLL("user32.dll")
+	procEnumWindows              = user32.NewProc("EnumWindows")
+	procShutdownBlockReasonQuery = user32.NewProc("ShutdownBlockReasonQuery")
+	procGetWindowTextW           = user32.NewProc("GetWindowTextW")
+	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
+)
+
+// NewCallback slots are never freed, so the EnumWindows callback is created once
+// and collects into found while enumMu is held
+var (
+	enumMu       sync.Mutex
+	found        []Inhibitor
+	enumCallback = syscall.NewCallback(blockReason)
+)
+
+// Inhibitors returns the windows that registered a reason to block the shutdown with
+// ShutdownBlockReasonCreate. They are enumerated in the session of the user logged on
+// at the console; without a user nothing is holding the shutdown back.
+func (windowsBackend) Inhibitors(mode string) ([]Inhibitor, error) {
+	if !Halts(mode) && mode != "logoff" {
+		return nil, nil
+	}
+	out, err := inSession("inhibitors")
+	if err == errNoSession {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var inhibitors []Inhibitor
+	if err := json.Unmarshal(out, &inhibitors); err != nil {
+		return nil, fmt.Errorf("%s inhibitors: %v", SessionHelper, err)
+	}
+	return inhibitors, nil
+}
+
+// blockingWindows enumerates the windows of the desktop the process runs on that block the shutdown
+func blockingWindows() []Inhibitor {
+	if procShutdownBlockReasonQuery.Find() != nil {
+		return nil
+	}
+	enumMu.Lock()
+	defer enumMu.Unlock()
+	found = nil
+	procEnumWindows.Call(enumCallback, 0)
+	return found
+}
+
+// blockReason is the EnumWindows callback, it adds hwnd to found if it blocks the shutdown
+func blockReason(hwnd uintptr, _ uintptr) uintptr {
+	var size uint32
+	if r, _, _ := procShutdownBlockReasonQuery.Call(hwnd, 0, uintptr(unsafe.Pointer(&size))); r == 0 || size == 0 {
+		return 1
+	}
+	reason := make([]uint16, size)
+	procShutdownBlockReasonQuery.Call(hwnd, uintptr(unsafe.Pointer(&reason[0])), uintptr(unsafe.Pointer(&size)))
+
+	title := make([]uint16, 256)
+	procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&title[0])), uintptr(len(title)))
+	var pid uint32
+	procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+
+	found = append(found, Inhibitor{
+		Who: syscall.UTF16ToString(title),
+		Why: syscall.UTF16ToString(reason),
+		PID: pid,
+	})
+	return 1
+}
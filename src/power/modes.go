@@ -0,0 +1,87 @@
+// Package power performs the shutdown, sleep, reboot, logoff and screen operations
+package power
+
+import (
+	"codans.com/autoshut/src/i18n"
+)
+
+// Get localized operation mode name
+func OperationName(mode string) string {
+	switch mode {
+	case "shutdown":
+		return i18n.T("mode_shutdown")
+	case "hibernate":
+		return i18n.T("mode_hibernate")
+	case "reboot":
+		return i18n.T("mode_reboot")
+	case "logoff":
+		return i18n.T("mode_logoff")
+	case "suspend":
+		return i18n.T("mode_suspend")
+	case "hybrid-sleep":
+		return i18n.T("mode_hybrid_sleep")
+	case "suspend-then-hibernate":
+		return i18n.T("mode_suspend_then_hibernate")
+	case "lock-session":
+		return i18n.T("mode_lock_session")
+	case "display-off":
+		return i18n.T("mode_display_off")
+	case "force-shutdown":
+		return i18n.T("mode_force_shutdown")
+	case "force-reboot":
+		return i18n.T("mode_force_reboot")
+	default:
+		return mode
+	}
+}
+
+// Modes lists the supported operation modes
+var Modes = []string{
+	"shutdown", "hibernate", "reboot", "logoff",
+	"suspend", "hybrid-sleep", "suspend-then-hibernate", "lock-session", "display-off",
+}
+
+// ValidMode reports whether mode is an operation mode this platform supports
+func ValidMode(mode string) bool {
+	if unsupported[mode] {
+		return false
+	}
+	for _, m := range Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidStep reports whether mode may be used as a step of the escalation chain.
+// Besides the operation modes these are the forced shutdown and reboot, which
+// close applications without asking them.
+func ValidStep(mode string) bool {
+	switch mode {
+	case "force-shutdown", "force-reboot":
+		return true
+	default:
+		return ValidMode(mode)
+	}
+}
+
+// Suspends reports whether mode puts the machine to sleep, so it comes back later
+func Suspends(mode string) bool {
+	switch mode {
+	case "hibernate", "suspend", "hybrid-sleep", "suspend-then-hibernate":
+		return true
+	default:
+		return false
+	}
+}
+
+// Halts reports whether mode shuts the machine down or restarts it
+func Halts(mode string) bool {
+	switch mode {
+	case "shutdown", "reboot", "force-shutdown", "force-reboot":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,144 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/logind"
+)
+
+// unsupported lists the operation modes this platform cannot request
+var unsupported = map[string]bool{}
+
+// linuxBackend asks systemd-logind to change the power state
+type linuxBackend struct {
+	logind *logind.Client
+}
+
+// NewBackend returns the power backend for this platform
+func NewBackend() Backend {
+	return NewLogindBackend(logind.NewSystemBus())
+}
+
+// NewLogindBackend returns a backend that talks to systemd-logind over bus
+func NewLogindBackend(bus logind.Bus) Backend {
+	return &linuxBackend{logind: logind.NewClient(bus)}
+}
+
+// RunSessionHelper is only used by the Windows service, logind reaches the user's session
+func RunSessionHelper(action string, out io.Writer) error {
+	return fmt.Errorf("%s is only used on Windows", SessionHelper)
+}
+
+func (b *linuxBackend) Perform(op Operation) error {
+	switch op.Mode {
+	case "shutdown":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_shutdown")))
+		return b.logind.PowerOff()
+	case "reboot":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_reboot")))
+		return b.logind.Reboot()
+	case "logoff":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_logoff")))
+		session, _, err := b.logind.ActiveSession()
+		if err != nil {
+			return err
+		}
+		return b.logind.TerminateSession(session)
+	case "hibernate":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_hibernate")))
+		return b.logind.Hibernate()
+	case "suspend":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_suspend")))
+		return b.logind.Suspend()
+	case "hybrid-sleep":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_hybrid_sleep")))
+		return b.logind.HybridSleep()
+	case "suspend-then-hibernate":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_suspend_then_hibernate")))
+		return b.logind.SuspendThenHibernate()
+	case "lock-session":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_lock_session")))
+		session, _, err := b.logind.ActiveSession()
+		if err != nil {
+			return err
+		}
+		return b.logind.LockSession(session)
+	case "display-off":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_display_off")))
+		return b.displayOff()
+	case "force-shutdown":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_force_shutdown")))
+		return systemctl("poweroff", "--force")
+	case "force-reboot":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_force_reboot")))
+		return systemctl("reboot", "--force")
+	default:
+		return fmt.Errorf("unknown operation mode %q", op.Mode)
+	}
+}
+
+// displayOff turns the monitors of the active session off with DPMS. The service
+// runs outside the session, so xset runs as the session's user on its X11 display;
+// Wayland sessions have no common interface for this.
+func (b *linuxBackend) displayOff() error {
+	_, path, err := b.logind.ActiveSession()
+	if err != nil {
+		return err
+	}
+	display, user, err := b.logind.SessionDisplay(path)
+	if err != nil {
+		return err
+	}
+	if display == "" {
+		return fmt.Errorf("display-off needs an X11 session")
+	}
+	out, err := exec.Command("runuser", "-u", user, "--", "xset", "-display", display, "dpms", "force", "off").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xset: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Inhibitors returns the applications holding a blocking logind inhibitor lock on mode.
+// Delay locks are left out, logind waits for them only a few seconds.
+func (b *linuxBackend) Inhibitors(mode string) ([]Inhibitor, error) {
+	what := "shutdown"
+	if Suspends(mode) {
+		what = "sleep"
+	}
+	locks, err := b.logind.ListInhibitors()
+	if err != nil {
+		return nil, err
+	}
+	var inhibitors []Inhibitor
+	for _, l := range locks {
+		if l.Mode != "block" {
+			continue
+		}
+		for _, w := range strings.Split(l.What, ":") {
+			if w == what {
+				inhibitors = append(inhibitors, Inhibitor{Who: l.Who, Why: l.Why, PID: l.PID})
+				break
+			}
+		}
+	}
+	return inhibitors, nil
+}
+
+// systemctl runs systemctl with args. With --force the services are not stopped
+// first and applications are killed, so no inhibitor can hold the shutdown back.
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %v %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
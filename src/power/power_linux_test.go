@@ -0,0 +1,118 @@
+//go:build linux
+// +build linux
+
+package power
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codans.com/autoshut/src/logind"
+	"github.com/godbus/dbus/v5"
+)
+
+func TestLogindBackendPerform(t *testing.T) {
+	session := dbus.ObjectPath("/org/freedesktop/login1/session/c2")
+	tests := []struct {
+		mode  string
+		calls []logind.FakeCall
+	}{
+		{"shutdown", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.PowerOffWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"reboot", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.RebootWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"hibernate", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.HibernateWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"suspend", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.SuspendWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"hybrid-sleep", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.HybridSleepWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"suspend-then-hibernate", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.SuspendThenHibernateWithFlags", Args: []interface{}{uint64(1)}},
+		}},
+		{"logoff", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1/seat/seat0", Method: "org.freedesktop.DBus.Properties.Get", Args: []interface{}{"org.freedesktop.login1.Seat", "ActiveSession"}},
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.TerminateSession", Args: []interface{}{"c2"}},
+		}},
+		{"lock-session", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1/seat/seat0", Method: "org.freedesktop.DBus.Properties.Get", Args: []interface{}{"org.freedesktop.login1.Seat", "ActiveSession"}},
+			{Path: "/org/freedesktop/login1", Method: "org.freedesktop.login1.Manager.LockSession", Args: []interface{}{"c2"}},
+		}},
+		{"display-off", []logind.FakeCall{
+			{Path: "/org/freedesktop/login1/seat/seat0", Method: "org.freedesktop.DBus.Properties.Get", Args: []interface{}{"org.freedesktop.login1.Seat", "ActiveSession"}},
+			{Path: session, Method: "org.freedesktop.DBus.Properties.Get", Args: []interface{}{"org.freedesktop.login1.Session", "Display"}},
+			{Path: session, Method: "org.freedesktop.DBus.Properties.Get", Args: []interface{}{"org.freedesktop.login1.Session", "Name"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			bus := logind.NewFakeBus("c2")
+			// Wayland 会话没有 X11 显示，display-off 在调用 xset 之前失败
+			bus.Properties["org.freedesktop.login1.Session.Display"] = ""
+			bus.Properties["org.freedesktop.login1.Session.Name"] = "kid"
+
+			err := NewLogindBackend(bus).Perform(Operation{Mode: tt.mode, Reason: "test"})
+			if tt.mode == "display-off" {
+				if err == nil || !strings.Contains(err.Error(), "X11") {
+					t.Errorf("Perform(display-off) = %v, want an X11 error", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Perform(%s) = %v", tt.mode, err)
+			}
+			if !reflect.DeepEqual(bus.Calls, tt.calls) {
+				t.Errorf("calls = %+v, want %+v", bus.Calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestLogindBackendErrors(t *testing.T) {
+	bus := logind.NewFakeBus("c2")
+	bus.Errors["org.freedesktop.login1.Manager.HibernateWithFlags"] = errors.New("Sleep verb not supported")
+	err := NewLogindBackend(bus).Perform(Operation{Mode: "hibernate"})
+	if err == nil || !strings.Contains(err.Error(), "logind Hibernate") {
+		t.Errorf("Perform(hibernate) = %v, want the logind error", err)
+	}
+
+	if err := NewLogindBackend(bus).Perform(Operation{Mode: "standby"}); err == nil {
+		t.Errorf("Perform(standby) = nil, want an unknown mode error")
+	}
+}
+
+func TestLogindBackendInhibitors(t *testing.T) {
+	bus := logind.NewFakeBus("c2")
+	bus.Replies["org.freedesktop.login1.Manager.ListInhibitors"] = []interface{}{[][]interface{}{
+		{"shutdown:sleep", "LibreOffice", "Unsaved document", "block", uint32(1000), uint32(4242)},
+		{"sleep", "NetworkManager", "Disconnect first", "delay", uint32(0), uint32(612)},
+		{"handle-lid-switch", "GNOME", "Lid", "block", uint32(1000), uint32(1500)},
+		{"sleep", "Transmission", "Downloading", "block", uint32(1000), uint32(5001)},
+	}}
+	b := NewLogindBackend(bus).(Inhibited)
+
+	tests := []struct {
+		mode string
+		want []Inhibitor
+	}{
+		{"shutdown", []Inhibitor{{Who: "LibreOffice", Why: "Unsaved document", PID: 4242}}},
+		{"hibernate", []Inhibitor{
+			{Who: "LibreOffice", Why: "Unsaved document", PID: 4242},
+			{Who: "Transmission", Why: "Downloading", PID: 5001},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := b.Inhibitors(tt.mode)
+		if err != nil {
+			t.Fatalf("Inhibitors(%s) = %v", tt.mode, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Inhibitors(%s) = %+v, want %+v", tt.mode, got, tt.want)
+		}
+	}
+}
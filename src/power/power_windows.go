@@ -0,0 +1,152 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"fmt"
+	"log"
+	"syscall"
+
+	"codans.com/autoshut/src/i18n"
+	. "github.com/CodyGuo/win"
+)
+
+var (
+	powrprof            = syscall.NewLazyDLL("powrprof.dll")
+	procSetSuspendState = powrprof.NewProc("SetSuspendState")
+	procLockWorkStation = user32.NewProc("LockWorkStation")
+	procPostMessageW    = user32.NewProc("PostMessageW")
+)
+
+// Broadcast message that turns the monitors off
+const (
+	hwndBroadcast   = 0xffff
+	wmSysCommand    = 0x0112
+	scMonitorPower  = 0xf170
+	monitorPowerOff = 2
+)
+
+// unsupported lists the operation modes Windows cannot request
+var unsupported = map[string]bool{"hybrid-sleep": true, "suspend-then-hibernate": true}
+
+// windowsBackend uses ExitWindowsEx and powrprof.dll
+type windowsBackend struct{}
+
+// NewBackend returns the power backend for this platform
+func NewBackend() Backend {
+	return windowsBackend{}
+}
+
+func (windowsBackend) Perform(op Operation) error {
+	switch op.Mode {
+	case "shutdown":
+		return shutdown()
+	case "hibernate":
+		return hibernate()
+	case "reboot":
+		return reboot()
+	case "logoff":
+		return logoff()
+	case "suspend":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_suspend")))
+		return setSuspendState(false)
+	case "hybrid-sleep", "suspend-then-hibernate":
+		// SetSuspendState 只能睡眠或休眠，混合睡眠和延迟休眠由电源选项决定，无法单独请求
+		return fmt.Errorf("operation mode %q is not supported on Windows", op.Mode)
+	case "lock-session":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_lock_session")))
+		_, err := inSession("lock-session")
+		return err
+	case "display-off":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_display_off")))
+		_, err := inSession("display-off")
+		return err
+	case "force-shutdown":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_force_shutdown")))
+		return exitWindows(EWX_SHUTDOWN | EWX_FORCE)
+	case "force-reboot":
+		log.Println(i18n.T("executing_operation", i18n.T("mode_force_reboot")))
+		return exitWindows(EWX_REBOOT | EWX_FORCE)
+	default:
+		return fmt.Errorf("unknown operation mode %q", op.Mode)
+	}
+}
+
+func shutdown() error {
+	log.Println(i18n.T("executing_operation", i18n.T("mode_shutdown")))
+	return exitWindows(EWX_SHUTDOWN)
+}
+
+func hibernate() error {
+	log.Println(i18n.T("executing_operation", i18n.T("mode_hibernate")))
+	// 失败后由调度器的升级链继续
+	return setSuspendState(true)
+}
+
+func reboot() error {
+	log.Println(i18n.T("executing_operation", i18n.T("mode_reboot")))
+	return exitWindows(EWX_REBOOT)
+}
+
+func logoff() error {
+	log.Println(i18n.T("executing_operation", i18n.T("mode_logoff")))
+	return exitWindows(EWX_LOGOFF)
+}
+
+// setSuspendState hibernates or suspends the machine. The call returns once it has resumed.
+func setSuspendState(hibernate bool) error {
+	if err := procSetSuspendState.Find(); err != nil {
+		return err
+	}
+	getPrivileges()
+	var h uintptr
+	if hibernate {
+		h = 1
+	}
+	// SetSuspendState(bHibernate, bForce, bWakeupEventsDisabled)
+	r, _, err := procSetSuspendState.Call(h, 0, 0)
+	if r == 0 {
+		return fmt.Errorf("SetSuspendState failed: %v", err)
+	}
+	return nil
+}
+
+// lockWorkStation locks the session the process runs in. The service runs it in the
+// user's session with inSession.
+func lockWorkStation() error {
+	if r, _, err := procLockWorkStation.Call(); r == 0 {
+		return fmt.Errorf("LockWorkStation failed: %v", err)
+	}
+	return nil
+}
+
+// displayOff asks every top-level window of the desktop the process runs on to turn the
+// monitors off, the service runs it in the user's session with inSession. PostMessage
+// does not wait for windows that hang.
+func displayOff() error {
+	if r, _, err := procPostMessageW.Call(hwndBroadcast, wmSysCommand, scMonitorPower, monitorPowerOff); r == 0 {
+		return fmt.Errorf("PostMessage(SC_MONITORPOWER) failed: %v", err)
+	}
+	return nil
+}
+
+// exitWindows acquires the shutdown privilege and calls ExitWindowsEx
+func exitWindows(flags uint32) error {
+	getPrivileges()
+	if !ExitWindowsEx(flags, 0) {
+		return fmt.Errorf("ExitWindowsEx(0x%x) failed: error %d", flags, GetLastError())
+	}
+	return nil
+}
+
+func getPrivileges() {
+	var hToken HANDLE
+	var tkp TOKEN_PRIVILEGES
+
+	OpenProcessToken(GetCurrentProcess(), TOKEN_ADJUST_PRIVILEGES|TOKEN_QUERY, &hToken)
+	LookupPrivilegeValueA(nil, StringToBytePtr(SE_SHUTDOWN_NAME), &tkp.Privileges[0].Luid)
+	tkp.PrivilegeCount = 1
+	tkp.Privileges[0].Attributes = SE_PRIVILEGE_ENABLED
+	AdjustTokenPrivileges(hToken, false, &tkp, 0, nil, nil)
+}
@@ -0,0 +1,132 @@
+//go:build windows
+// +build windows
+
+package power
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                         = syscall.NewLazyDLL("kernel32.dll")
+	wtsapi32                         = syscall.NewLazyDLL("wtsapi32.dll")
+	procWTSGetActiveConsoleSessionId = kernel32.NewProc("WTSGetActiveConsoleSessionId")
+	procProcessIdToSessionId         = kernel32.NewProc("ProcessIdToSessionId")
+	procWTSQueryUserToken            = wtsapi32.NewProc("WTSQueryUserToken")
+)
+
+const (
+	noSession      = 0xFFFFFFFF
+	errorNoToken   = syscall.Errno(1008)
+	createNoWindow = 0x08000000
+
+	// sessionTimeout is how long the helper may take before it is terminated
+	sessionTimeout = 30 * time.Second
+)
+
+// errNoSession is returned by inSession if nobody is logged on at the console
+var errNoSession = errors.New("no user is logged on at the console")
+
+// RunSessionHelper carries out action in the session the process runs in and writes
+// the result to out. The service starts it with inSession.
+func RunSessionHelper(action string, out io.Writer) error {
+	switch action {
+	case "inhibitors":
+		return json.NewEncoder(out).Encode(blockingWindows())
+	case "lock-session":
+		return lockWorkStation()
+	case "display-off":
+		return displayOff()
+	default:
+		return fmt.Errorf("unknown %s action %q", SessionHelper, action)
+	}
+}
+
+// inSession runs action in the session of the user logged on at the console and returns
+// its output. The service runs in session 0, whose desktop has none of the user's
+// windows, so it starts the executable again with the user's token on the user's desktop.
+// A process that already runs in that session carries out action itself.
+func inSession(action string) ([]byte, error) {
+	session, _, _ := procWTSGetActiveConsoleSessionId.Call()
+	if uint32(session) == noSession {
+		return nil, errNoSession
+	}
+	var own uint32
+	if r, _, _ := procProcessIdToSessionId.Call(uintptr(os.Getpid()), uintptr(unsafe.Pointer(&own))); r != 0 && own == uint32(session) {
+		var out bytes.Buffer
+		err := RunSessionHelper(action, &out)
+		return out.Bytes(), err
+	}
+
+	var token syscall.Token
+	if r, _, err := procWTSQueryUserToken.Call(session, uintptr(unsafe.Pointer(&token))); r == 0 {
+		if err == errorNoToken {
+			return nil, errNoSession
+		}
+		return nil, fmt.Errorf("WTSQueryUserToken failed: %v", err)
+	}
+	defer token.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有管道的写入端由子进程继承
+	sa := syscall.SecurityAttributes{Length: uint32(unsafe.Sizeof(syscall.SecurityAttributes{})), InheritHandle: 1}
+	var r, w syscall.Handle
+	if err := syscall.CreatePipe(&r, &w, &sa, 0); err != nil {
+		return nil, fmt.Errorf("CreatePipe failed: %v", err)
+	}
+	syscall.SetHandleInformation(r, syscall.HANDLE_FLAG_INHERIT, 0)
+	reader := os.NewFile(uintptr(r), SessionHelper)
+	defer reader.Close()
+
+	si := syscall.StartupInfo{
+		Cb:        uint32(unsafe.Sizeof(syscall.StartupInfo{})),
+		Desktop:   syscall.StringToUTF16Ptr(`winsta0\default`),
+		Flags:     syscall.STARTF_USESTDHANDLES,
+		StdOutput: w,
+		StdErr:    w,
+	}
+	var pi syscall.ProcessInformation
+	cmdLine := syscall.EscapeArg(exe) + " " + SessionHelper + " " + action
+	err = syscall.CreateProcessAsUser(token, nil, syscall.StringToUTF16Ptr(cmdLine), nil, nil, true, createNoWindow, nil, nil, &si, &pi)
+	syscall.CloseHandle(w)
+	if err != nil {
+		return nil, fmt.Errorf("CreateProcessAsUser failed: %v", err)
+	}
+	defer syscall.CloseHandle(pi.Thread)
+	defer syscall.CloseHandle(pi.Process)
+
+	output := make(chan []byte, 1)
+	go func() {
+		out, _ := io.ReadAll(reader)
+		output <- out
+	}()
+	// 超时的子进程被终止，管道随之关闭
+	if ev, _ := syscall.WaitForSingleObject(pi.Process, uint32(sessionTimeout/time.Millisecond)); ev == syscall.WAIT_TIMEOUT {
+		syscall.TerminateProcess(pi.Process, 1)
+		<-output
+		return nil, fmt.Errorf("%s %s did not finish within %s", SessionHelper, action, sessionTimeout)
+	}
+	out := <-output
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(pi.Process, &code); err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("%s %s: %s", SessionHelper, action, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
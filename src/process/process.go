@@ -0,0 +1,104 @@
+// Package process lists and terminates running processes
+package process
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Process is one running process
+type Process struct {
+	PID  int
+	Name string // Executable name, e.g. "steam" or "steam.exe"
+}
+
+// String returns the process as "name (pid)"
+func (p Process) String() string {
+	return fmt.Sprintf("%s (%d)", p.Name, p.PID)
+}
+
+// Table enumerates and terminates processes
+type Table interface {
+	List() ([]Process, error)
+	Kill(pid int) error
+}
+
+// Config is the "processes" section of the config file
+type Config struct {
+	Allow           []string `json:"allow,omitempty"`             // Processes that defer the operation while they run
+	MaxDeferMinutes int      `json:"max_defer_minutes,omitempty"` // Longest deferral by allowlisted processes
+	Block           []string `json:"block,omitempty"`             // Processes terminated inside the windows
+}
+
+// Normalize returns the name used for matching: lower case, without ".exe"
+func Normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".exe")
+}
+
+// Match returns the processes whose name is in names
+func Match(procs []Process, names []string) []Process {
+	if len(names) == 0 {
+		return nil
+	}
+	wanted := map[string]bool{}
+	for _, n := range names {
+		wanted[Normalize(n)] = true
+	}
+	var matched []Process
+	for _, p := range procs {
+		if wanted[Normalize(p.Name)] {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// Join returns the processes as a comma separated list
+func Join(procs []Process) string {
+	names := make([]string, len(procs))
+	for i, p := range procs {
+		names[i] = p.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Fake is a Table for tests. Kill removes the process and records its PID.
+type Fake struct {
+	mu     sync.Mutex
+	procs  []Process
+	killed []int
+}
+
+// Start adds a process
+func (f *Fake) Start(pid int, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.procs = append(f.procs, Process{PID: pid, Name: name})
+}
+
+// Killed returns the PIDs passed to Kill, in order
+func (f *Fake) Killed() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.killed...)
+}
+
+func (f *Fake) List() ([]Process, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Process(nil), f.procs...), nil
+}
+
+func (f *Fake) Kill(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, p := range f.procs {
+		if p.PID == pid {
+			f.procs = append(f.procs[:i], f.procs[i+1:]...)
+			f.killed = append(f.killed, pid)
+			return nil
+		}
+	}
+	return fmt.Errorf("no process %d", pid)
+}
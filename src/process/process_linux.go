@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// procTable reads the process list from /proc
+type procTable struct {
+	root string
+}
+
+// NewTable returns the process table for this platform
+func NewTable() Table {
+	return NewProcTable("/proc")
+}
+
+// NewProcTable returns a Table that lists the processes below root, e.g. a fake /proc in tests
+func NewProcTable(root string) Table {
+	return procTable{root: root}
+}
+
+func (t procTable) List() ([]Process, error) {
+	entries, err := os.ReadDir(t.root)
+	if err != nil {
+		return nil, err
+	}
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		// 进程可能已经退出，忽略读取错误
+		if name := t.name(e.Name()); name != "" {
+			procs = append(procs, Process{PID: pid, Name: name})
+		}
+	}
+	return procs, nil
+}
+
+// name returns the executable name of the process: the base name of /proc/<pid>/exe,
+// else of argv[0]. comm is only the fallback, e.g. for kernel threads, because the
+// kernel cuts it to 15 bytes and "minecraft-launcher" would never match.
+func (t procTable) name(pid string) string {
+	if exe, err := os.Readlink(filepath.Join(t.root, pid, "exe")); err == nil && exe != "" {
+		return filepath.Base(strings.TrimSuffix(exe, " (deleted)"))
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(t.root, pid, "cmdline")); err == nil {
+		if argv0 := strings.SplitN(string(cmdline), "\x00", 2)[0]; argv0 != "" {
+			return filepath.Base(argv0)
+		}
+	}
+	comm, err := os.ReadFile(filepath.Join(t.root, pid, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// Kill asks the process to terminate with SIGTERM
+func (t procTable) Kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,67 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestProcTableNames(t *testing.T) {
+	root := t.TempDir()
+	write := func(pid, name, content string) {
+		t.Helper()
+		dir := filepath.Join(root, pid)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(pid, target string) {
+		t.Helper()
+		if err := os.Symlink(target, filepath.Join(root, pid, "exe")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// comm 被内核截断为 15 字节
+	write("100", "comm", "minecraft-launc\n")
+	write("100", "cmdline", "/opt/minecraft/minecraft-launcher\x00--workDir\x00/home/kid\x00")
+	link("100", "/opt/minecraft/minecraft-launcher")
+	// exe 无法读取时使用 argv[0]
+	write("200", "comm", "steamwebhelper-\n")
+	write("200", "cmdline", "/home/kid/.steam/ubuntu12_64/steamwebhelper-sandbox\x00-lang=en\x00")
+	// 已删除的可执行文件
+	write("300", "comm", "firefox\n")
+	link("300", "/usr/lib/firefox/firefox (deleted)")
+	// 内核线程只有 comm
+	write("400", "comm", "kworker/0:1\n")
+	write("400", "cmdline", "")
+	// 不是进程
+	write("self-test", "comm", "ignored\n")
+
+	procs, err := NewProcTable(root).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	want := []Process{
+		{PID: 100, Name: "minecraft-launcher"},
+		{PID: 200, Name: "steamwebhelper-sandbox"},
+		{PID: 300, Name: "firefox"},
+		{PID: 400, Name: "kworker/0:1"},
+	}
+	if !reflect.DeepEqual(procs, want) {
+		t.Errorf("List() = %+v, want %+v", procs, want)
+	}
+
+	if m := Match(procs, []string{"Minecraft-Launcher"}); len(m) != 1 || m[0].PID != 100 {
+		t.Errorf("Match(minecraft-launcher) = %v", m)
+	}
+}
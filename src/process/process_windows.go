@@ -0,0 +1,49 @@
+//go:build windows
+// +build windows
+
+package process
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// windowsTable uses a Toolhelp32 snapshot to list processes
+type windowsTable struct{}
+
+// NewTable returns the process table for this platform
+func NewTable() Table {
+	return windowsTable{}
+}
+
+func (windowsTable) List() ([]Process, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := syscall.Process32First(snapshot, &entry); err != nil {
+		return nil, err
+	}
+	var procs []Process
+	for {
+		procs = append(procs, Process{PID: int(entry.ProcessID), Name: syscall.UTF16ToString(entry.ExeFile[:])})
+		if err := syscall.Process32Next(snapshot, &entry); err != nil {
+			break
+		}
+	}
+	return procs, nil
+}
+
+// Kill terminates the process immediately; Windows has no polite equivalent of SIGTERM for services
+func (windowsTable) Kill(pid int) error {
+	h, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(h)
+	return syscall.TerminateProcess(h, 1)
+}
@@ -0,0 +1,120 @@
+// Package queue keeps one-off scheduled operations and persists them across restarts
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/fsutil"
+)
+
+// Entry is one scheduled operation
+type Entry struct {
+	ID     int       `json:"id"`
+	At     time.Time `json:"at"`
+	Mode   string    `json:"mode"`
+	Warned bool      `json:"warned,omitempty"` // The warning dialog was already shown
+}
+
+// state is the persisted queue
+type state struct {
+	NextID  int     `json:"next_id"`
+	Entries []Entry `json:"entries"`
+}
+
+// Queue holds the pending entries, ordered by time
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	nextID  int
+	entries []Entry
+}
+
+// Open loads the queue from path. A missing file starts an empty queue.
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path, nextID: 1}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if st.NextID > q.nextID {
+		q.nextID = st.NextID
+	}
+	q.entries = st.Entries
+	q.sort()
+	return q, nil
+}
+
+// Add schedules mode at the given time and returns the new entry
+func (q *Queue) Add(at time.Time, mode string) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := Entry{ID: q.nextID, At: at, Mode: mode}
+	q.nextID++
+	q.entries = append(q.entries, e)
+	q.sort()
+	return e, q.save()
+}
+
+// Cancel removes the entry with the given ID and reports whether it existed
+func (q *Queue) Cancel(id int) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, e := range q.entries {
+		if e.ID == id {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return true, q.save()
+		}
+	}
+	return false, nil
+}
+
+// MarkWarned records that the warning for the entry was shown
+func (q *Queue) MarkWarned(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.entries {
+		if q.entries[i].ID == id {
+			q.entries[i].Warned = true
+			return q.save()
+		}
+	}
+	return nil
+}
+
+// List returns a copy of the pending entries, earliest first
+func (q *Queue) List() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Entry(nil), q.entries...)
+}
+
+func (q *Queue) sort() {
+	sort.SliceStable(q.entries, func(i, j int) bool { return q.entries[i].At.Before(q.entries[j].At) })
+}
+
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	entries := q.entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(state{NextID: q.nextID, Entries: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(q.path, data, 0644)
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueOrder(t *testing.T) {
+	q, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	q.Add(base.Add(time.Hour), "shutdown")
+	q.Add(base, "hibernate")
+	q.Add(base.Add(30*time.Minute), "suspend")
+
+	entries := q.List()
+	if len(entries) != 3 || entries[0].ID != 2 || entries[1].ID != 3 || entries[2].ID != 1 {
+		t.Fatalf("List() = %+v, want IDs 2, 3, 1 ordered by time", entries)
+	}
+
+	if ok, err := q.Cancel(3); !ok || err != nil {
+		t.Errorf("Cancel(3) = %v, %v, want true", ok, err)
+	}
+	if ok, _ := q.Cancel(3); ok {
+		t.Error("Cancel(3) twice = true, want false")
+	}
+	// 取消后编号不会重复使用
+	if e, _ := q.Add(base, "shutdown"); e.ID != 4 {
+		t.Errorf("Add() after Cancel() = ID %d, want 4", e.ID)
+	}
+}
+
+func TestQueuePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)
+	q.Add(base.Add(time.Hour), "shutdown")
+	q.Add(base, "hibernate")
+	q.Add(base.Add(2*time.Hour), "reboot")
+	q.Cancel(3)
+	q.MarkWarned(2)
+
+	// 重新打开后恢复条目、警告状态和下一个编号
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := reopened.List()
+	if len(entries) != 2 {
+		t.Fatalf("List() after reopening = %+v, want two entries", entries)
+	}
+	if e := entries[0]; e.ID != 2 || !e.At.Equal(base) || e.Mode != "hibernate" || !e.Warned {
+		t.Errorf("entries[0] = %+v, want the warned hibernation", e)
+	}
+	if e := entries[1]; e.ID != 1 || !e.At.Equal(base.Add(time.Hour)) || e.Mode != "shutdown" || e.Warned {
+		t.Errorf("entries[1] = %+v, want the shutdown", e)
+	}
+	if e, _ := reopened.Add(base, "suspend"); e.ID != 4 {
+		t.Errorf("Add() after reopening = ID %d, want 4", e.ID)
+	}
+
+	reopened.Cancel(1)
+	reopened.Cancel(2)
+	reopened.Cancel(4)
+	empty, err := Open(path)
+	if err != nil || len(empty.List()) != 0 {
+		t.Errorf("Open() of an emptied queue = %+v, %v", empty.List(), err)
+	}
+}
+
+func TestOpenMissingOrInvalid(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(filepath.Join(dir, "missing.json"))
+	if err != nil || len(q.List()) != 0 {
+		t.Errorf("Open() without a file = %+v, %v, want an empty queue", q, err)
+	}
+	if e, _ := q.Add(time.Now(), "shutdown"); e.ID != 1 {
+		t.Errorf("first ID = %d, want 1", e.ID)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(invalid); err == nil {
+		t.Error("Open() of an invalid file succeeded, want an error")
+	}
+}
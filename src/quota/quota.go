@@ -0,0 +1,152 @@
+// Package quota tracks daily screen time and persists it across restarts
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/fsutil"
+)
+
+// MaxTickGap is the longest gap between two ticks that still counts as use.
+// Longer gaps mean the machine was off, hibernated or suspended.
+const MaxTickGap = time.Minute
+
+// Config is the "quota" section of the config file
+type Config struct {
+	DailyMinutes int    `json:"daily_minutes"`          // 0 disables the quota
+	Mode         string `json:"mode,omitempty"`         // Operation when the quota runs out, the global mode if empty
+	IdleMinutes  int    `json:"idle_minutes,omitempty"` // Input idle time after which use stops counting
+}
+
+// Enabled reports whether a daily quota is configured
+func (c Config) Enabled() bool {
+	return c.DailyMinutes > 0
+}
+
+// state is the persisted part of the tracker
+type state struct {
+	Date           string `json:"date"` // 2006-01-02
+	UsedSeconds    int64  `json:"used_seconds"`
+	GrantedSeconds int64  `json:"granted_seconds"`
+}
+
+// Tracker accumulates active time for the current day
+type Tracker struct {
+	mu      sync.Mutex
+	path    string
+	daily   time.Duration
+	date    string
+	used    time.Duration
+	granted time.Duration
+	last    time.Time // Time of the previous tick
+	saved   int64     // Used minutes at the last save
+}
+
+// Open loads the tracker state from path. A missing file starts an empty day.
+func Open(path string, daily time.Duration) (*Tracker, error) {
+	t := &Tracker{path: path, daily: daily}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	t.date = st.Date
+	t.used = time.Duration(st.UsedSeconds) * time.Second
+	t.granted = time.Duration(st.GrantedSeconds) * time.Second
+	t.saved = int64(t.used / time.Minute)
+	return t, nil
+}
+
+// Tick records the time since the previous tick as use if active is true
+func (t *Tracker) Tick(now time.Time, active bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := t.rollover(now)
+	if !t.last.IsZero() && active {
+		if elapsed := now.Sub(t.last); elapsed > 0 && elapsed <= MaxTickGap {
+			t.used += elapsed
+		}
+	}
+	t.last = now
+
+	// 每使用满一分钟保存一次
+	if minutes := int64(t.used / time.Minute); changed || minutes != t.saved {
+		t.saved = minutes
+		return t.save()
+	}
+	return nil
+}
+
+// rollover starts a new day when the date changes and reports whether it did
+func (t *Tracker) rollover(now time.Time) bool {
+	today := now.Format("2006-01-02")
+	if t.date == today {
+		return false
+	}
+	t.date = today
+	t.used = 0
+	t.granted = 0
+	return true
+}
+
+// Remaining returns the unused time left today, which may be negative
+func (t *Tracker) Remaining(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	return t.daily + t.granted - t.used
+}
+
+// Usage returns today's used time, the daily allowance and the extra time granted
+func (t *Tracker) Usage(now time.Time) (used, daily, granted time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	return t.used, t.daily, t.granted
+}
+
+// Grant adds extra time for today
+func (t *Tracker) Grant(now time.Time, extra time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	t.granted += extra
+	return t.save()
+}
+
+// Reset clears today's used and granted time
+func (t *Tracker) Reset(now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	t.used = 0
+	t.granted = 0
+	t.saved = 0
+	return t.save()
+}
+
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state{
+		Date:           t.date,
+		UsedSeconds:    int64(t.used / time.Second),
+		GrantedSeconds: int64(t.granted / time.Second),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(t.path, data, 0644)
+}
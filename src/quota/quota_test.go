@@ -0,0 +1,114 @@
+package quota
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrackerCountsActiveTime(t *testing.T) {
+	tr, err := Open("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tr.Tick(start, true)
+
+	// 第一次检查之前的时间不计入
+	if used, _, _ := tr.Usage(start); used != 0 {
+		t.Fatalf("used %v after the first tick, want 0", used)
+	}
+	tr.Tick(start.Add(30*time.Second), true)
+	tr.Tick(start.Add(time.Minute), false)
+	tr.Tick(start.Add(time.Minute+MaxTickGap), true)
+	if used, _, _ := tr.Usage(start); used != 30*time.Second+MaxTickGap {
+		t.Errorf("used %v, want 30s active plus one full gap", used)
+	}
+
+	// 超过 MaxTickGap 的间隔表示关机、休眠或睡眠
+	before, _, _ := tr.Usage(start)
+	tr.Tick(start.Add(time.Hour), true)
+	if used, _, _ := tr.Usage(start); used != before {
+		t.Errorf("used %v after a long gap, want %v", used, before)
+	}
+	if r := tr.Remaining(start.Add(time.Hour)); r != time.Hour-before {
+		t.Errorf("Remaining() = %v, want %v", r, time.Hour-before)
+	}
+}
+
+func TestTrackerRollover(t *testing.T) {
+	tr, err := Open("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evening := time.Date(2026, 10, 19, 23, 59, 0, 0, time.UTC)
+	tr.Tick(evening, true)
+	tr.Tick(evening.Add(30*time.Second), true)
+	tr.Grant(evening, 15*time.Minute)
+	if r := tr.Remaining(evening); r != 75*time.Minute-30*time.Second {
+		t.Fatalf("Remaining() = %v before midnight, want 1h14m30s", r)
+	}
+
+	// 新的一天重新开始，追加的时间也不保留；跨过午夜的间隔计入新的一天
+	tr.Tick(evening.Add(70*time.Second), true)
+	used, daily, granted := tr.Usage(evening.Add(70 * time.Second))
+	if used != 40*time.Second || daily != time.Hour || granted != 0 {
+		t.Errorf("Usage() after midnight = %v, %v, %v, want 40s, 1h, 0", used, daily, granted)
+	}
+}
+
+func TestTrackerGrantAndReset(t *testing.T) {
+	tr, err := Open("", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for i := 0; i <= 24; i++ {
+		tr.Tick(now.Add(time.Duration(i)*30*time.Second), true)
+	}
+	now = now.Add(12 * time.Minute)
+	if r := tr.Remaining(now); r != -2*time.Minute {
+		t.Fatalf("Remaining() = %v, want -2m after 12 minutes of use", r)
+	}
+	tr.Grant(now, 5*time.Minute)
+	if r := tr.Remaining(now); r != 3*time.Minute {
+		t.Errorf("Remaining() = %v after granting 5m, want 3m", r)
+	}
+	tr.Reset(now)
+	if used, _, granted := tr.Usage(now); used != 0 || granted != 0 {
+		t.Errorf("Usage() after Reset = %v used, %v granted, want 0", used, granted)
+	}
+	if r := tr.Remaining(now); r != 10*time.Minute {
+		t.Errorf("Remaining() = %v after Reset, want 10m", r)
+	}
+}
+
+func TestTrackerPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	tr, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for i := 0; i <= 4; i++ {
+		if err := tr.Tick(now.Add(time.Duration(i)*30*time.Second), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.Grant(now, 20*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新启动后继续当天的使用量
+	reopened, err := Open(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, _, granted := reopened.Usage(now); used != 2*time.Minute || granted != 20*time.Minute {
+		t.Errorf("Usage() after reopening = %v used, %v granted, want 2m, 20m", used, granted)
+	}
+	// 第二天打开时从零开始
+	if used, _, granted := reopened.Usage(now.AddDate(0, 0, 1)); used != 0 || granted != 0 {
+		t.Errorf("Usage() the next day = %v used, %v granted, want 0", used, granted)
+	}
+}
@@ -0,0 +1,527 @@
+// Package remote implements the TCP/UDP remote control protocol
+package remote
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"codans.com/autoshut/src/activity"
+	"codans.com/autoshut/src/calendar"
+	"codans.com/autoshut/src/extension"
+	"codans.com/autoshut/src/history"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/idle"
+	"codans.com/autoshut/src/lockout"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/process"
+	"codans.com/autoshut/src/queue"
+	"codans.com/autoshut/src/quota"
+	"codans.com/autoshut/src/scheduler"
+	"codans.com/autoshut/src/tamper"
+	"codans.com/autoshut/src/wake"
+)
+
+// Controller processes remote commands against the shared configuration
+type Controller struct {
+	Config      *scheduler.Config
+	Backend     power.Backend
+	Scheduler   *scheduler.Scheduler
+	Quota       *quota.Tracker    // nil if no screen-time quota is configured
+	Idle        idle.Detector     // nil if idle detection is unavailable
+	Busy        *activity.Guard   // nil if no activity guard is configured
+	Processes   process.Table     // nil if no process lists are configured
+	Tamper      *tamper.Detector  // nil if clock tamper detection is disabled
+	Queue       *queue.Queue      // nil if one-off operations are unavailable
+	Extensions  *extension.Ledger // nil if no extension policy is configured
+	Lockout     *lockout.Tracker  // nil if the lockout is disabled
+	Wake        wake.Timer        // nil if wake timers are unavailable
+	History     *history.Log      // nil if no operation history is kept
+	Version     string
+	VersionDate string
+}
+
+// NewController creates a Controller for cfg. The operations run through Scheduler,
+// which has to be set before serving; backend only provides the dry-run records.
+func NewController(cfg *scheduler.Config, backend power.Backend, version, versionDate string) *Controller {
+	return &Controller{Config: cfg, Backend: backend, Version: version, VersionDate: versionDate}
+}
+
+// Process remote commands
+func (c *Controller) Process(cmd string) string {
+	// Split command and parameters
+	parts := strings.Fields(strings.ToLower(cmd))
+	if len(parts) == 0 {
+		return i18n.T("enter_command")
+	}
+
+	mainCmd := parts[0]
+	switch mainCmd {
+	case "version":
+		return i18n.T("version_info", i18n.T("app_name"), c.Version, c.VersionDate)
+
+	case "settime_start_menu":
+		return i18n.T("enter_start_time")
+
+	case "settime_end_menu":
+		return i18n.T("enter_end_time")
+	case "shutdown":
+		go c.perform("shutdown")
+		return i18n.T("operation_successful", i18n.T("mode_shutdown"))
+
+	case "hibernate":
+		if err := c.perform("hibernate"); err != nil {
+			return i18n.T("operation_failed", i18n.T("mode_hibernate"), err)
+		}
+		return i18n.T("operation_successful", i18n.T("mode_hibernate"))
+
+	case "reboot":
+		go c.perform("reboot")
+		return i18n.T("operation_successful", i18n.T("mode_reboot"))
+
+	case "logoff":
+		go c.perform("logoff")
+		return i18n.T("operation_successful", i18n.T("mode_logoff"))
+
+	case "suspend", "hybrid-sleep", "suspend-then-hibernate":
+		if !power.ValidMode(mainCmd) {
+			return i18n.T("invalid_mode")
+		}
+		if err := c.perform(mainCmd); err != nil {
+			return i18n.T("operation_failed", power.OperationName(mainCmd), err)
+		}
+		return i18n.T("operation_successful", power.OperationName(mainCmd))
+
+	case "lock-session", "display-off":
+		if err := c.perform(mainCmd); err != nil {
+			return i18n.T("operation_failed", power.OperationName(mainCmd), err)
+		}
+		return i18n.T("operation_successful", power.OperationName(mainCmd))
+
+	case "setmode":
+		if len(parts) < 2 {
+			return i18n.T("invalid_mode")
+		}
+
+		newMode := parts[1]
+		if !power.ValidMode(newMode) {
+			return i18n.T("invalid_mode")
+		}
+
+		c.Config.Update(func(s *scheduler.Settings) {
+			s.Mode = newMode
+		})
+		return i18n.T("mode_set_success", power.OperationName(newMode))
+
+	case "status":
+		cfg := c.Config.Get()
+		status := i18n.T("current_status", power.OperationName(cfg.Mode), c.Version)
+		if cfg.TimeZone != "" {
+			status += "\n" + i18n.T("time_zone_status", cfg.TimeZone, c.now().Format("2006-01-02 15:04 MST"))
+		}
+		today := cfg.WindowsOn(c.now())
+		for _, w := range cfg.Windows {
+			status += "\n" + i18n.T("window_status", w.Name, w.Days, w.Start, w.End,
+				power.OperationName(w.ModeOr(cfg.Mode)))
+			// 日出日落窗口显示今天的实际时间
+			if w.IsSolar() {
+				for _, t := range today {
+					if t.Name == w.Name {
+						status += " " + i18n.T("window_solar_today", t.Start.Clock(), t.End.Clock())
+					}
+				}
+			}
+		}
+		for _, t := range cfg.Cron {
+			next := "-"
+			if n := t.Next(c.now()); !n.IsZero() {
+				next = n.Format("2006-01-02 15:04")
+			}
+			status += "\n" + i18n.T("cron_status", t.Name, t.Schedule, next, power.OperationName(t.ModeOr(cfg.Mode)))
+		}
+		for _, p := range cfg.Idle {
+			status += "\n" + i18n.T("idle_policy_status", p.Name, p.Days, p.Start, p.End, p.Minutes,
+				power.OperationName(p.ModeOr(cfg.Mode)))
+		}
+		if c.Wake != nil && len(cfg.Wake) > 0 {
+			if next := cfg.NextWake(c.now()); !next.IsZero() {
+				status += "\n" + i18n.T("wake_next", next.Format("2006-01-02 15:04"))
+			}
+		}
+		if c.Idle != nil && len(cfg.Idle) > 0 {
+			if d, err := c.Idle.IdleTime(); err == nil {
+				status += "\n" + i18n.T("idle_time_status", formatDuration(d))
+			}
+		}
+
+		if c.Quota != nil {
+			status += "\n" + c.quotaStatus()
+		}
+		if c.Queue != nil && len(c.Queue.List()) > 0 {
+			status += "\n" + c.queueList()
+		}
+
+		if c.Extensions != nil && cfg.Extensions.Enabled() {
+			status += "\n" + c.extensionStatus(true)
+		}
+
+		// 最近一次锁定的窗口和之后的开机次数
+		if c.Lockout != nil {
+			if records := c.Lockout.List(); len(records) > 0 {
+				status += "\n" + c.lockoutRecord(records[len(records)-1])
+			}
+		}
+
+		if c.Tamper != nil && c.Tamper.Offset() != 0 {
+			status += "\n" + c.tamperStatus()
+		}
+
+		// 繁忙保护的阈值和最近的推迟决定
+		if cfg.Busy.Enabled() {
+			status += "\n" + i18n.T("busy_status", cfg.Busy, cfg.Busy.MaxPostponeMinutes)
+			if c.Busy != nil {
+				for _, d := range c.Busy.Decisions() {
+					status += "\n" + i18n.T("busy_decision", d.Time.Format("2006-01-02 15:04:05"),
+						power.OperationName(d.Mode), d.Reason, i18n.T("busy_verdict_"+string(d.Verdict)), d.Load)
+				}
+			}
+		}
+
+		// 演练模式下附加已记录的操作
+		if recorder, ok := c.Backend.(power.Recorder); ok {
+			records := recorder.Records()
+			status += "\n" + i18n.T("dry_run_status", len(records))
+			for _, r := range records {
+				status += "\n" + i18n.T("dry_run_record",
+					r.Time.Format("2006-01-02 15:04:05"), power.OperationName(r.Mode), r.Reason)
+			}
+		}
+		return status
+
+	case "help":
+		return i18n.T("help_text")
+
+	case "setwarning":
+		if len(parts) < 2 {
+			return "用法: setwarning on/off [minutes]\n例如: setwarning on 5"
+		}
+
+		switch parts[1] {
+		case "on":
+			var minutes int
+			c.Config.Update(func(s *scheduler.Settings) {
+				s.ShowWarning = true
+				// 如果指定了分钟数
+				if len(parts) >= 3 {
+					if mins, err := strconv.Atoi(parts[2]); err == nil && mins > 0 {
+						s.WarningMinutes = mins
+					}
+				}
+				minutes = s.WarningMinutes
+			})
+			return fmt.Sprintf("警告已启用，提前%d分钟显示", minutes)
+
+		case "off":
+			c.Config.Update(func(s *scheduler.Settings) {
+				s.ShowWarning = false
+			})
+			return "警告已禁用"
+
+		default:
+			return "用法: setwarning on/off [minutes]\n例如: setwarning on 5"
+		}
+
+	case "settime":
+		// settime [window] start|end HH:MM, without a window name the first window is changed
+		if len(parts) < 3 {
+			return i18n.T("invalid_time_format")
+		}
+
+		windowName := ""
+		if len(parts) >= 4 {
+			windowName = parts[1]
+			parts = append(parts[:1], parts[2:]...)
+		}
+
+		timeType := parts[1]  // start or end
+		timeValue := parts[2] // HH:MM, sunrise or sunset with optional offset
+
+		// Parse time
+		newTime, err := scheduler.ParseTimeOfDay(timeValue)
+		if err != nil {
+			return i18n.T("invalid_time_format")
+		}
+		if timeType != "start" && timeType != "end" {
+			return i18n.T("invalid_time_type")
+		}
+		if newTime.Solar != "" && c.Config.Get().Coordinates == nil {
+			return i18n.T("solar_needs_coordinates")
+		}
+
+		// Set time
+		var name string
+		found := true
+		c.Config.Update(func(s *scheduler.Settings) {
+			index := 0
+			if windowName != "" {
+				index = s.FindWindow(windowName)
+			}
+			if index < 0 || index >= len(s.Windows) {
+				found = false
+				return
+			}
+			w := &s.Windows[index]
+			if timeType == "start" {
+				w.Start = newTime
+			} else {
+				w.End = newTime
+			}
+			name = w.Name
+		})
+		if !found {
+			return i18n.T("window_not_found", windowName)
+		}
+
+		return i18n.T("window_time_set_success", name, i18n.T("time_"+timeType), newTime)
+
+	case "exceptions":
+		// exceptions [days]: list calendar exceptions in the coming days
+		days := 30
+		if len(parts) >= 2 {
+			if n, err := strconv.Atoi(parts[1]); err == nil && n > 0 {
+				days = n
+			}
+		}
+		return c.listExceptions(days)
+
+	case "processes":
+		// processes: show which allowlisted processes defer the operation and which blocklisted ones run
+		return c.processStatus()
+
+	case "schedule":
+		// schedule in <duration> [mode] | schedule at <HH:MM> [mode] | schedule list | schedule cancel <id>
+		return c.scheduleCommand(parts[1:])
+
+	case "extend":
+		// extend list | extend request | extend approve <id> | extend deny <id>
+		return c.extendCommand(parts[1:])
+
+	case "wake":
+		// wake [status] | wake at <HH:MM> [days] | wake off [days]
+		return c.wakeCommand(parts[1:])
+
+	case "lockout":
+		// lockout: list the enforced windows and the attempts to power on afterwards
+		return c.lockoutStatus()
+
+	case "history":
+		// history: list the recent operations and the escalation steps they reached
+		return c.historyStatus()
+
+	case "simulate":
+		// simulate [YYYY-MM-DD] [days]: run the schedule on a fake clock
+		cfg := c.Config.Get()
+		from, to, err := scheduler.ParseSimulationRange(parts[1:], c.now(), cfg.Location())
+		if err != nil {
+			return i18n.T("simulate_usage", scheduler.MaxSimulationDays)
+		}
+		return scheduler.FormatSimulation(scheduler.Simulate(cfg, from, to), from, to, cfg.Location())
+
+	case "tamper":
+		// tamper status | tamper accept <pin>
+		if c.Tamper == nil {
+			return i18n.T("tamper_disabled")
+		}
+		if len(parts) < 2 || parts[1] == "status" {
+			return c.tamperStatus()
+		}
+		if parts[1] == "accept" {
+			// 否则修改时间后任何人都能解除锁定
+			if len(parts) < 3 {
+				return i18n.T("tamper_usage")
+			}
+			if reply := c.approved("tamper accept", parts[2]); reply != "" {
+				return reply
+			}
+			c.Tamper.Accept(time.Now(), tamper.Uptime())
+			c.Config.Notify()
+			log.Printf(i18n.T("tamper_accepted"))
+			return i18n.T("tamper_accepted")
+		}
+		return i18n.T("tamper_usage")
+
+	case "quota":
+		// quota status | quota grant <minutes> <pin> | quota reset <pin>
+		if c.Quota == nil {
+			return i18n.T("quota_disabled")
+		}
+		if len(parts) < 2 || parts[1] == "status" {
+			return c.quotaStatus()
+		}
+		// 远程命令没有身份验证，追加或重置屏幕时间需要批准密码
+		switch parts[1] {
+		case "grant":
+			if len(parts) < 4 {
+				return i18n.T("quota_usage")
+			}
+			minutes, err := strconv.Atoi(parts[2])
+			if err != nil || minutes <= 0 {
+				return i18n.T("quota_usage")
+			}
+			if reply := c.approved("quota grant", parts[3]); reply != "" {
+				return reply
+			}
+			if err := c.Quota.Grant(c.now(), time.Duration(minutes)*time.Minute); err != nil {
+				log.Printf(i18n.T("log_quota_save_failed", err))
+			}
+			c.Config.Notify()
+			return i18n.T("quota_granted", minutes) + "\n" + c.quotaStatus()
+		case "reset":
+			if len(parts) < 3 {
+				return i18n.T("quota_usage")
+			}
+			if reply := c.approved("quota reset", parts[2]); reply != "" {
+				return reply
+			}
+			if err := c.Quota.Reset(c.now()); err != nil {
+				log.Printf(i18n.T("log_quota_save_failed", err))
+			}
+			c.Config.Notify()
+			return i18n.T("quota_reset") + "\n" + c.quotaStatus()
+		default:
+			return i18n.T("quota_usage")
+		}
+
+	case "language":
+		if len(parts) < 2 {
+			return i18n.T("please_specify_language")
+		}
+
+		langCode := parts[1]
+		if langCode != "en" && langCode != "zh-Hans" {
+			return i18n.T("invalid_language")
+		}
+
+		i18n.SetLanguage(langCode)
+
+		// Use the new language to respond
+		return i18n.T("language_changed", i18n.GetLanguageName(langCode))
+
+	default:
+		return i18n.T("unknown_command")
+	}
+}
+
+// perform runs a remotely requested operation immediately, without the warning dialog.
+// The scheduler runs it in its loop like its own operations and records it in the history.
+func (c *Controller) perform(mode string) error {
+	return c.Scheduler.Perform(mode, "remote")
+}
+
+// listExceptions describes the calendar exceptions between today and the given number of days ahead
+func (c *Controller) listExceptions(days int) string {
+	cfg := c.Config.Get()
+	now := c.now()
+	upcoming := cfg.Calendar.Upcoming(now, now.AddDate(0, 0, days))
+	if len(upcoming) == 0 {
+		return i18n.T("no_exceptions", days)
+	}
+
+	result := i18n.T("upcoming_exceptions", days)
+	for _, e := range upcoming {
+		action := i18n.T("exception_action_" + string(e.Action))
+		if e.Action == calendar.ActionWindow {
+			action += " " + e.Window
+			if e.Mode != "" {
+				action += " " + power.OperationName(e.Mode)
+			}
+		}
+		result += "\n" + i18n.T("exception_item",
+			e.Start.Format("2006-01-02"), e.LastDay().Format("2006-01-02"), e.Summary, action)
+	}
+	return result
+}
+
+// quotaStatus describes today's screen-time usage
+func (c *Controller) quotaStatus() string {
+	now := c.now()
+	used, daily, granted := c.Quota.Usage(now)
+	remaining := c.Quota.Remaining(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return i18n.T("quota_status", formatDuration(used), formatDuration(daily), formatDuration(granted), formatDuration(remaining))
+}
+
+// formatDuration formats d as hours and minutes, e.g. 1h05m
+func formatDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// processStatus lists the running processes from the allow and block lists
+func (c *Controller) processStatus() string {
+	cfg := c.Config.Get()
+	if c.Processes == nil || (len(cfg.Processes.Allow) == 0 && len(cfg.Processes.Block) == 0) {
+		return i18n.T("processes_disabled")
+	}
+	procs, err := c.Processes.List()
+	if err != nil {
+		return i18n.T("processes_list_failed", err)
+	}
+
+	var lines []string
+	if len(cfg.Processes.Allow) > 0 {
+		lines = append(lines, i18n.T("processes_allow", strings.Join(cfg.Processes.Allow, ", "), cfg.Processes.MaxDeferMinutes))
+		if running := process.Match(procs, cfg.Processes.Allow); len(running) > 0 {
+			lines = append(lines, i18n.T("processes_deferring", process.Join(running)))
+		} else {
+			lines = append(lines, i18n.T("processes_not_deferring"))
+		}
+	}
+	if len(cfg.Processes.Block) > 0 {
+		lines = append(lines, i18n.T("processes_block", strings.Join(cfg.Processes.Block, ", ")))
+		if running := process.Match(procs, cfg.Processes.Block); len(running) > 0 {
+			lines = append(lines, i18n.T("processes_blocked_running", process.Join(running)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tamperStatus shows the tamper settings, the current offset and the recent clock changes
+func (c *Controller) tamperStatus() string {
+	cfg := c.Config.Get()
+	lines := []string{i18n.T("tamper_status", cfg.Tamper.Response, cfg.Tamper.Threshold(), c.Tamper.Offset())}
+	for _, e := range c.Tamper.Events() {
+		lines = append(lines, i18n.T("tamper_event", e))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lockoutStatus lists the enforced windows with every attempt to power on afterwards
+func (c *Controller) lockoutStatus() string {
+	cfg := c.Config.Get()
+	if c.Lockout == nil || !cfg.Lockout.Enabled {
+		return i18n.T("lockout_disabled")
+	}
+	lines := []string{i18n.T("lockout_status", cfg.Lockout.Grace())}
+	records := c.Lockout.List()
+	if len(records) == 0 {
+		lines = append(lines, i18n.T("lockout_none"))
+	}
+	for _, r := range records {
+		lines = append(lines, c.lockoutRecord(r))
+		for _, a := range r.Attempts {
+			lines = append(lines, i18n.T("lockout_attempt", a.In(cfg.Location()).Format("2006-01-02 15:04:05")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lockoutRecord describes one enforced window
+func (c *Controller) lockoutRecord(r lockout.Record) string {
+	enforced := r.Enforced.In(c.Config.Get().Location()).Format("2006-01-02 15:04:05")
+	return i18n.T("lockout_record", r.Window, r.Night, enforced, len(r.Attempts))
+}
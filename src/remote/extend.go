@@ -0,0 +1,122 @@
+package remote
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"codans.com/autoshut/src/extension"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// extensionListSize is the number of recent requests shown by "extend list"
+const extensionListSize = 10
+
+// extendCommand handles "extend list", "extend request", "extend approve <id> <pin>" and "extend deny <id> <pin>"
+func (c *Controller) extendCommand(args []string) string {
+	cfg := c.Config.Get()
+	if c.Extensions == nil || !cfg.Extensions.Enabled() {
+		return i18n.T("extension_disabled")
+	}
+	if len(args) == 0 || args[0] == "list" || args[0] == "status" {
+		return c.extensionStatus(false)
+	}
+
+	switch args[0] {
+	case "request":
+		// 在没有弹窗可以回答的系统上，通过远程命令申请延长
+		now := c.now()
+		night, ok := cfg.Night(now)
+		if !ok {
+			return i18n.T("extension_no_window")
+		}
+		mode := cfg.Mode
+		if w, ok := cfg.ActiveWindow(now); ok {
+			mode = w.ModeOr(cfg.Mode)
+		}
+		r, err := c.Extensions.Request(now, night, mode, cfg.Extensions)
+		if err != nil {
+			return i18n.T("extension_save_failed", err)
+		}
+		c.Config.Notify()
+		if r.Effective() {
+			log.Printf(i18n.T("log_extension_granted", r.ID, c.Extensions.Used(night), cfg.Extensions.PerNight))
+			return i18n.T("extension_granted", r.ID, r.Minutes)
+		}
+		log.Printf(i18n.T("log_extension_pending", r.ID, cfg.Extensions.PerNight))
+		return i18n.T("extension_pending", r.ID, r.ID)
+
+	case "approve", "deny":
+		// 远程命令没有身份验证，孩子也能发送，所以家长的决定需要批准密码
+		if len(args) < 3 {
+			return i18n.T("extension_usage")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return i18n.T("extension_usage")
+		}
+		if cfg.Extensions.ApprovalPIN == "" {
+			return i18n.T("extension_no_pin")
+		}
+		if !cfg.Extensions.Authorized(args[2]) {
+			log.Printf(i18n.T("log_extension_refused", id, args[0]))
+			return i18n.T("extension_wrong_pin")
+		}
+		decide := c.Extensions.Approve
+		if args[0] == "deny" {
+			decide = c.Extensions.Deny
+		}
+		r, ok, err := decide(id, c.now())
+		if err != nil {
+			return i18n.T("extension_save_failed", err)
+		}
+		if !ok {
+			return i18n.T("extension_not_pending", id)
+		}
+		c.Config.Notify()
+		msg := i18n.T("extension_"+string(r.Status), r.ID, r.Minutes)
+		log.Printf(msg)
+		return msg
+	}
+	return i18n.T("extension_usage")
+}
+
+// approved checks pin against the approval PIN of the extensions config, for commands
+// a child must not run: the remote commands are not authenticated. It returns the
+// reply if the PIN is not configured or wrong, and "" if command may run.
+func (c *Controller) approved(command, pin string) string {
+	cfg := c.Config.Get()
+	if cfg.Extensions.ApprovalPIN == "" {
+		return i18n.T("approval_no_pin", command)
+	}
+	if !cfg.Extensions.Authorized(pin) {
+		log.Printf(i18n.T("log_approval_refused", command))
+		return i18n.T("extension_wrong_pin")
+	}
+	return ""
+}
+
+// extensionStatus shows the policy, tonight's usage and the recent requests,
+// or only the requests still waiting for a parent if pendingOnly is set
+func (c *Controller) extensionStatus(pendingOnly bool) string {
+	cfg := c.Config.Get()
+	used := 0
+	if night, ok := cfg.Night(c.now()); ok {
+		used = c.Extensions.Used(night)
+	}
+	lines := []string{i18n.T("extension_status", cfg.Extensions.PerNight, cfg.Extensions.Minutes, used)}
+
+	requests := c.Extensions.List()
+	if len(requests) > extensionListSize {
+		requests = requests[len(requests)-extensionListSize:]
+	}
+	for _, r := range requests {
+		if pendingOnly && r.Status != extension.Pending {
+			continue
+		}
+		lines = append(lines, i18n.T("extension_item", r.ID, r.Time.In(cfg.Location()).Format("2006-01-02 15:04"),
+			power.OperationName(r.Mode), r.Minutes, i18n.T("extension_status_"+string(r.Status))))
+	}
+	return strings.Join(lines, "\n")
+}
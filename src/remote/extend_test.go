@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/extension"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/scheduler"
+)
+
+func TestExtendApproveNeedsPIN(t *testing.T) {
+	settings := scheduler.DefaultSettings()
+	settings.Extensions = extension.Config{PerNight: 0, Minutes: 15}
+	cfg := scheduler.NewConfig(settings)
+	ledger, err := extension.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewController(cfg, power.NewDryRun(), "test", "")
+	c.Extensions = ledger
+
+	// 超过每晚次数的申请等待家长批准
+	r, err := ledger.Request(time.Now(), "2026-10-19", "hibernate", settings.Extensions)
+	if err != nil || r.Status != extension.Pending {
+		t.Fatalf("Request() = %+v, %v, want a pending request", r, err)
+	}
+
+	if got := c.Process("extend approve 1"); got != i18n.T("extension_usage") {
+		t.Errorf("approve without PIN = %q", got)
+	}
+	if got := c.Process("extend approve 1 4711"); got != i18n.T("extension_no_pin") {
+		t.Errorf("approve without a configured PIN = %q", got)
+	}
+
+	cfg.Update(func(s *scheduler.Settings) { s.Extensions.ApprovalPIN = "4711" })
+	if got := c.Process("extend approve 1 1234"); got != i18n.T("extension_wrong_pin") {
+		t.Errorf("approve with a wrong PIN = %q", got)
+	}
+	if l := ledger.List(); l[0].Status != extension.Pending {
+		t.Fatalf("request is %s after a wrong PIN", l[0].Status)
+	}
+	if got := c.Process("extend approve #1 4711"); got != i18n.T("extension_approved", 1, 15) {
+		t.Errorf("approve with the PIN = %q", got)
+	}
+	if l := ledger.List(); l[0].Status != extension.Approved {
+		t.Errorf("request is %s after approving", l[0].Status)
+	}
+}
@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"strings"
+
+	"codans.com/autoshut/src/history"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// historyListSize is the number of recent operations shown by "history"
+const historyListSize = 10
+
+// historyStatus lists the recent operations with the escalation steps each of them reached
+func (c *Controller) historyStatus() string {
+	var entries []history.Entry
+	if c.History != nil {
+		entries = c.History.List()
+	}
+	if len(entries) == 0 {
+		return i18n.T("history_none")
+	}
+	if len(entries) > historyListSize {
+		entries = entries[len(entries)-historyListSize:]
+	}
+
+	loc := c.Config.Get().Location()
+	var lines []string
+	for _, e := range entries {
+		lines = append(lines, i18n.T("history_entry", e.ID, e.Time.In(loc).Format("2006-01-02 15:04:05"),
+			power.OperationName(e.Mode), e.Reason))
+		for _, s := range e.Steps {
+			at := s.Time.In(loc).Format("15:04:05")
+			result := i18n.T("history_" + string(s.Result))
+			if s.Error != "" {
+				lines = append(lines, i18n.T("history_step_error", at, power.OperationName(s.Mode), result, s.Error))
+			} else {
+				lines = append(lines, i18n.T("history_step", at, power.OperationName(s.Mode), result))
+			}
+			for _, b := range s.Blockers {
+				lines = append(lines, i18n.T("history_blocker", b))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,59 @@
+package remote
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/quota"
+	"codans.com/autoshut/src/scheduler"
+)
+
+func TestQuotaNeedsPIN(t *testing.T) {
+	settings := scheduler.DefaultSettings()
+	settings.Quota = quota.Config{DailyMinutes: 60}
+	cfg := scheduler.NewConfig(settings)
+	tracker, err := quota.Open("", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewController(cfg, power.NewDryRun(), "test", "")
+	c.Quota = tracker
+	remaining := func() time.Duration { return tracker.Remaining(c.now()) }
+
+	if got := c.Process("quota grant 30"); got != i18n.T("quota_usage") {
+		t.Errorf("grant without PIN = %q", got)
+	}
+	if got := c.Process("quota grant 30 4711"); got != i18n.T("approval_no_pin", "quota grant") {
+		t.Errorf("grant without a configured PIN = %q", got)
+	}
+
+	cfg.Update(func(s *scheduler.Settings) { s.Extensions.ApprovalPIN = "4711" })
+	if got := c.Process("quota grant 30 1234"); got != i18n.T("extension_wrong_pin") {
+		t.Errorf("grant with a wrong PIN = %q", got)
+	}
+	if r := remaining(); r != time.Hour {
+		t.Fatalf("Remaining() = %v after a wrong PIN, want 1h", r)
+	}
+	if got := c.Process("quota grant 30 4711"); !strings.HasPrefix(got, i18n.T("quota_granted", 30)) {
+		t.Errorf("grant with the PIN = %q", got)
+	}
+	if r := remaining(); r != 90*time.Minute {
+		t.Errorf("Remaining() = %v after the grant, want 1h30m", r)
+	}
+
+	if got := c.Process("quota reset"); got != i18n.T("quota_usage") {
+		t.Errorf("reset without PIN = %q", got)
+	}
+	if got := c.Process("quota reset 1234"); got != i18n.T("extension_wrong_pin") {
+		t.Errorf("reset with a wrong PIN = %q", got)
+	}
+	if got := c.Process("quota reset 4711"); !strings.HasPrefix(got, i18n.T("quota_reset")) {
+		t.Errorf("reset with the PIN = %q", got)
+	}
+	if r := remaining(); r != time.Hour {
+		t.Errorf("Remaining() = %v after the reset, want 1h", r)
+	}
+}
@@ -0,0 +1,117 @@
+package remote
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/scheduler"
+	"codans.com/autoshut/src/tamper"
+)
+
+// scheduleCommand handles "schedule in <duration> [mode]", "schedule at <HH:MM> [mode]",
+// "schedule list" and "schedule cancel <id>"
+func (c *Controller) scheduleCommand(args []string) string {
+	if c.Queue == nil {
+		return i18n.T("queue_disabled")
+	}
+	if len(args) == 0 || args[0] == "list" {
+		return c.queueList()
+	}
+
+	switch args[0] {
+	case "cancel":
+		if len(args) < 2 {
+			return i18n.T("queue_usage")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return i18n.T("queue_usage")
+		}
+		ok, err := c.Queue.Cancel(id)
+		if err != nil {
+			return i18n.T("queue_save_failed", err)
+		}
+		if !ok {
+			return i18n.T("queue_not_found", id)
+		}
+		c.Config.Notify()
+		log.Printf(i18n.T("queue_cancelled", id))
+		return i18n.T("queue_cancelled", id)
+
+	case "in", "at":
+		if len(args) < 2 {
+			return i18n.T("queue_usage")
+		}
+		now := c.now()
+		var at time.Time
+		if args[0] == "in" {
+			d, ok := parseDelay(args[1])
+			if !ok {
+				return i18n.T("queue_usage")
+			}
+			at = now.Add(d)
+		} else {
+			h, m, ok := scheduler.ParseClock(args[1])
+			if !ok {
+				return i18n.T("queue_usage")
+			}
+			at = scheduler.NextClock(now, h, m)
+		}
+
+		mode := c.Config.Get().Mode
+		if len(args) >= 3 {
+			mode = args[2]
+		}
+		if !power.ValidMode(mode) {
+			return i18n.T("invalid_mode")
+		}
+
+		e, err := c.Queue.Add(at, mode)
+		if err != nil {
+			return i18n.T("queue_save_failed", err)
+		}
+		c.Config.Notify()
+		log.Printf(i18n.T("queue_added", e.ID, power.OperationName(e.Mode), e.At.Format("2006-01-02 15:04:05")))
+		return i18n.T("queue_added", e.ID, power.OperationName(e.Mode), e.At.Format("2006-01-02 15:04:05"))
+	}
+	return i18n.T("queue_usage")
+}
+
+// queueList describes the pending one-off operations
+func (c *Controller) queueList() string {
+	entries := c.Queue.List()
+	if len(entries) == 0 {
+		return i18n.T("queue_empty")
+	}
+	now := c.now()
+	lines := []string{i18n.T("queue_header")}
+	for _, e := range entries {
+		lines = append(lines, i18n.T("queue_item", e.ID, power.OperationName(e.Mode),
+			e.At.Format("2006-01-02 15:04:05"), formatDuration(e.At.Sub(now))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// now returns the time the scheduler evaluates at: the trusted time if clock
+// changes are enforced, in the configured time zone
+func (c *Controller) now() time.Time {
+	cfg := c.Config.Get()
+	now := time.Now()
+	if c.Tamper != nil && cfg.Tamper.Response == tamper.ResponseEnforce {
+		now = c.Tamper.Trusted(now)
+	}
+	return now.In(cfg.Location())
+}
+
+// parseDelay parses "45m", "1h30m" or a plain number of minutes
+func parseDelay(value string) (time.Duration, bool) {
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return time.Duration(minutes) * time.Minute, minutes > 0
+	}
+	d, err := time.ParseDuration(value)
+	return d, err == nil && d > 0
+}
@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/queue"
+	"codans.com/autoshut/src/scheduler"
+)
+
+func TestParseDelay(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"45", 45 * time.Minute, true},
+		{"45m", 45 * time.Minute, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"90s", 90 * time.Second, true},
+		{"0", 0, false},
+		{"-5", 0, false},
+		{"0m", 0, false},
+		{"-1h", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDelay(tt.value)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseDelay(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestScheduleCommand(t *testing.T) {
+	settings := scheduler.DefaultSettings()
+	settings.Mode = "hibernate"
+	c := NewController(scheduler.NewConfig(settings), power.NewDryRun(), "test", "")
+	if got := c.Process("schedule in 45m"); got != i18n.T("queue_disabled") {
+		t.Errorf("schedule without a queue = %q", got)
+	}
+
+	q, err := queue.Open(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Queue = q
+	for _, cmd := range []string{"schedule in", "schedule in 0", "schedule in soon", "schedule at 25:00", "schedule at 7", "schedule cancel", "schedule cancel x", "schedule later"} {
+		if got := c.Process(cmd); got != i18n.T("queue_usage") {
+			t.Errorf("%s = %q, want the usage", cmd, got)
+		}
+	}
+	if got := c.Process("schedule in 45m reboot-now"); got != i18n.T("invalid_mode") {
+		t.Errorf("schedule with an unknown mode = %q", got)
+	}
+	if n := len(q.List()); n != 0 {
+		t.Fatalf("%d entries after invalid commands, want 0", n)
+	}
+
+	before := time.Now()
+	c.Process("schedule in 45m")
+	c.Process("schedule at 23:59 shutdown")
+	entries := q.List()
+	if len(entries) != 2 {
+		t.Fatalf("List() = %+v, want two entries", entries)
+	}
+	var in queue.Entry
+	for _, e := range entries {
+		if e.ID == 1 {
+			in = e
+		}
+	}
+	if in.Mode != "hibernate" || in.At.Before(before.Add(45*time.Minute)) || in.At.After(time.Now().Add(45*time.Minute)) {
+		t.Errorf("schedule in 45m added %+v, want a hibernation in 45 minutes", in)
+	}
+
+	if got := c.Process("schedule cancel #1"); got != i18n.T("queue_cancelled", 1) {
+		t.Errorf("schedule cancel #1 = %q", got)
+	}
+	if got := c.Process("schedule cancel 1"); got != i18n.T("queue_not_found", 1) {
+		t.Errorf("cancel of a cancelled entry = %q", got)
+	}
+	if entries := q.List(); len(entries) != 1 || entries[0].Mode != "shutdown" {
+		t.Errorf("List() after cancel = %+v, want the shutdown", entries)
+	}
+}
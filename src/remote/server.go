@@ -0,0 +1,214 @@
+package remote
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// Start TCP server for remote control
+func (c *Controller) StartTCPServer(port string) {
+	addr := fmt.Sprintf(":%s", port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("TCP server start failed: %v\n", err)
+		return
+	}
+	defer listener.Close()
+
+	log.Printf(i18n.T("log_tcp_server_started", port))
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf(i18n.T("log_accept_failed", err))
+			continue
+		}
+
+		go c.handleTCPConnection(conn)
+	}
+}
+
+// Handle TCP connection
+func (c *Controller) handleTCPConnection(conn net.Conn) {
+	defer conn.Close()
+
+	log.Printf(i18n.T("log_new_tcp_connection", conn.RemoteAddr().String()))
+
+	// Show welcome message and interactive menu
+	showWelcomeMenu(conn)
+
+	reader := bufio.NewReader(conn)
+
+	// Track current state
+	var waitingForStartTime bool = false
+	var waitingForEndTime bool = false
+
+	for {
+		// 显示命令提示符
+		if !waitingForStartTime && !waitingForEndTime {
+			conn.Write([]byte(fmt.Sprintf("\n请输入命令或菜单选项 [1-%d]: ", len(menuItems()))))
+		}
+
+		// Read user input
+		cmd, err := reader.ReadString('\n')
+		if err != nil {
+			log.Printf(i18n.T("log_command_read_failed", err))
+			break
+		}
+
+		cmd = strings.TrimSpace(cmd)
+
+		// 如果正在等待时间输入
+		if waitingForStartTime {
+			waitingForStartTime = false
+			response := c.Process("settime start " + cmd)
+			conn.Write([]byte("\n" + response + "\n"))
+			conn.Write([]byte("\n按回车返回菜单..."))
+			reader.ReadString('\n')
+			showWelcomeMenu(conn)
+			continue
+		} else if waitingForEndTime {
+			waitingForEndTime = false
+			response := c.Process("settime end " + cmd)
+			conn.Write([]byte("\n" + response + "\n"))
+			conn.Write([]byte("\n按回车返回菜单..."))
+			reader.ReadString('\n')
+			showWelcomeMenu(conn)
+			continue
+		}
+
+		// 处理菜单选项
+		if n, err := strconv.Atoi(cmd); err == nil && n >= 1 && n <= len(menuItems()) {
+			cmd = getCommandFromMenuOption(cmd)
+		}
+
+		// 如果用户输入"menu"，显示菜单
+		if cmd == "menu" {
+			showWelcomeMenu(conn)
+			continue
+		}
+
+		// 处理特殊菜单命令
+		if cmd == "settime_start_menu" {
+			conn.Write([]byte("\n请输入开始时间（格式为 HH:MM），例如 22:00: "))
+			waitingForStartTime = true
+			continue
+		} else if cmd == "settime_end_menu" {
+			conn.Write([]byte("\n请输入结束时间（格式为 HH:MM），例如 06:00: "))
+			waitingForEndTime = true
+			continue
+		}
+
+		// 处理命令并返回响应
+		response := c.Process(cmd)
+		conn.Write([]byte("\n" + response + "\n"))
+
+		// 如果是状态命令或帮助命令，显示菜单
+		if cmd == "status" || cmd == "help" {
+			conn.Write([]byte("\n按回车继续..."))
+			reader.ReadString('\n')
+			showWelcomeMenu(conn)
+		}
+	}
+}
+
+// menuItem is one entry of the welcome menu
+type menuItem struct {
+	label   string
+	command string
+	mode    string // Operation mode the entry runs or selects, empty for other commands
+}
+
+// menuItems returns the entries of the welcome menu, numbered from 1. Entries for
+// operation modes this platform does not support are left out.
+func menuItems() []menuItem {
+	all := []menuItem{
+		{i18n.T("menu_status"), "status", ""},
+		{i18n.T("menu_hibernate"), "hibernate", "hibernate"},
+		{i18n.T("menu_shutdown"), "shutdown", "shutdown"},
+		{i18n.T("menu_reboot"), "reboot", "reboot"},
+		{i18n.T("menu_logoff"), "logoff", "logoff"},
+		{i18n.T("menu_set_mode") + " (Hibernate)", "setmode hibernate", "hibernate"},
+		{i18n.T("menu_set_mode") + " (Shutdown)", "setmode shutdown", "shutdown"},
+		{i18n.T("menu_set_start_time"), "settime_start_menu", ""},
+		{i18n.T("menu_set_end_time"), "settime_end_menu", ""},
+		{"启用关机警告", "setwarning on", ""},
+		{"禁用关机警告", "setwarning off", ""},
+		{i18n.T("menu_suspend"), "suspend", "suspend"},
+		{i18n.T("menu_hybrid_sleep"), "hybrid-sleep", "hybrid-sleep"},
+		{i18n.T("menu_suspend_hibernate"), "suspend-then-hibernate", "suspend-then-hibernate"},
+		{i18n.T("menu_lock_session"), "lock-session", "lock-session"},
+		{i18n.T("menu_display_off"), "display-off", "display-off"},
+		{i18n.T("menu_set_mode") + " (Suspend)", "setmode suspend", "suspend"},
+	}
+	items := make([]menuItem, 0, len(all))
+	for _, item := range all {
+		if item.mode == "" || power.ValidMode(item.mode) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Show welcome menu
+func showWelcomeMenu(conn net.Conn) {
+	menu := i18n.T("welcome_title") + "\n\n"
+	for i, item := range menuItems() {
+		menu += fmt.Sprintf(i18n.T("menu_item"), i+1, item.label) + "\n"
+	}
+	menu += "\n"
+	menu += i18n.T("menu_prompt")
+
+	// 发送菜单到客户端
+	conn.Write([]byte(menu))
+}
+
+// 根据菜单选项获取命令
+func getCommandFromMenuOption(option string) string {
+	items := menuItems()
+	if n, err := strconv.Atoi(option); err == nil && n >= 1 && n <= len(items) {
+		return items[n-1].command
+	}
+	return "help"
+}
+
+// Start UDP server for remote control
+func (c *Controller) StartUDPServer(port string) {
+	addr := fmt.Sprintf(":%s", port)
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		log.Printf(i18n.T("log_udp_addr_failed", err))
+		return
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		log.Printf(i18n.T("log_udp_listen_failed", err))
+		return
+	}
+	defer conn.Close()
+
+	log.Printf(i18n.T("log_udp_server_started", port))
+
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Printf(i18n.T("log_udp_read_failed", err))
+			continue
+		}
+
+		cmd := strings.TrimSpace(string(buf[:n]))
+		log.Printf(i18n.T("log_udp_command", addr.String(), cmd))
+
+		response := c.Process(cmd)
+		conn.WriteToUDP([]byte(response), addr)
+	}
+}
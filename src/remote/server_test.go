@@ -0,0 +1,92 @@
+package remote
+
+import (
+	"strconv"
+	"testing"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/scheduler"
+)
+
+func TestMenuItems(t *testing.T) {
+	items := menuItems()
+	commands := map[string]bool{}
+	for _, item := range items {
+		commands[item.command] = true
+		if item.mode != "" && !power.ValidMode(item.mode) {
+			t.Errorf("menu offers %q, which this platform does not support", item.command)
+		}
+	}
+	// 每个支持的模式都能直接执行
+	for _, mode := range power.Modes {
+		if commands[mode] != power.ValidMode(mode) {
+			t.Errorf("menu has %s = %v, want %v", mode, commands[mode], power.ValidMode(mode))
+		}
+	}
+
+	if got := getCommandFromMenuOption("1"); got != "status" {
+		t.Errorf("option 1 = %q, want status", got)
+	}
+	if got := getCommandFromMenuOption("3"); got != "shutdown" {
+		t.Errorf("option 3 = %q, want shutdown", got)
+	}
+	last := items[len(items)-1].command
+	if got := getCommandFromMenuOption(strconv.Itoa(len(items))); got != last {
+		t.Errorf("last option = %q, want %q", got, last)
+	}
+	for _, option := range []string{"0", "-1", strconv.Itoa(len(items) + 1), "x"} {
+		if got := getCommandFromMenuOption(option); got != "help" {
+			t.Errorf("option %q = %q, want help", option, got)
+		}
+	}
+}
+
+func TestModeCommands(t *testing.T) {
+	cfg := scheduler.NewConfig(scheduler.DefaultSettings())
+	backend := power.NewDryRun()
+	sched := scheduler.New(cfg, func(string, int) bool { return true }, backend)
+	go sched.Run()
+	defer sched.Stop()
+	c := NewController(cfg, backend, "test", "")
+	c.Scheduler = sched
+
+	for _, mode := range []string{"suspend", "hybrid-sleep", "suspend-then-hibernate"} {
+		before := len(backend.Records())
+		got := c.Process(mode)
+		if !power.ValidMode(mode) {
+			// 平台不支持的模式不执行
+			if got != i18n.T("invalid_mode") || len(backend.Records()) != before {
+				t.Errorf("%s on an unsupported platform = %q", mode, got)
+			}
+			continue
+		}
+		if got != i18n.T("operation_successful", power.OperationName(mode)) {
+			t.Errorf("%s = %q", mode, got)
+		}
+		if r := backend.Records(); len(r) != before+1 || r[len(r)-1].Mode != mode {
+			t.Errorf("%s recorded %+v", mode, r)
+		}
+	}
+
+	for _, mode := range power.Modes {
+		got := c.Process("setmode " + mode)
+		if power.ValidMode(mode) {
+			if got != i18n.T("mode_set_success", power.OperationName(mode)) || cfg.Get().Mode != mode {
+				t.Errorf("setmode %s = %q, mode %q", mode, got, cfg.Get().Mode)
+			}
+		} else if got != i18n.T("invalid_mode") {
+			t.Errorf("setmode %s on an unsupported platform = %q", mode, got)
+		}
+	}
+
+	cfg.Update(func(s *scheduler.Settings) { s.Mode = "hibernate" })
+	for _, cmd := range []string{"setmode standby", "setmode", "setmode force-shutdown"} {
+		if got := c.Process(cmd); got != i18n.T("invalid_mode") {
+			t.Errorf("%s = %q, want invalid_mode", cmd, got)
+		}
+	}
+	if mode := cfg.Get().Mode; mode != "hibernate" {
+		t.Errorf("mode after rejected commands = %q, want hibernate", mode)
+	}
+}
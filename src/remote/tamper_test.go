@@ -0,0 +1,47 @@
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/scheduler"
+	"codans.com/autoshut/src/tamper"
+)
+
+func TestTamperAcceptNeedsPIN(t *testing.T) {
+	settings := scheduler.DefaultSettings()
+	settings.Tamper = tamper.Config{Response: tamper.ResponseEnforce}
+	cfg := scheduler.NewConfig(settings)
+	c := NewController(cfg, power.NewDryRun(), "test", "")
+	c.Tamper = tamper.NewDetector("")
+
+	// 时间被调快一小时
+	base := time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	c.Tamper.Observe(base, time.Hour, tamper.DefaultThreshold)
+	if _, ok := c.Tamper.Observe(base.Add(time.Hour), time.Hour, tamper.DefaultThreshold); !ok {
+		t.Fatal("Observe() did not report the jump")
+	}
+
+	if got := c.Process("tamper accept"); got != i18n.T("tamper_usage") {
+		t.Errorf("accept without PIN = %q", got)
+	}
+	if got := c.Process("tamper accept 4711"); got != i18n.T("approval_no_pin", "tamper accept") {
+		t.Errorf("accept without a configured PIN = %q", got)
+	}
+	cfg.Update(func(s *scheduler.Settings) { s.Extensions.ApprovalPIN = "4711" })
+	if got := c.Process("tamper accept 1234"); got != i18n.T("extension_wrong_pin") {
+		t.Errorf("accept with a wrong PIN = %q", got)
+	}
+	if off := c.Tamper.Offset(); off != -time.Hour {
+		t.Fatalf("Offset() = %v after refused accepts, want -1h", off)
+	}
+
+	if got := c.Process("tamper accept 4711"); got != i18n.T("tamper_accepted") {
+		t.Errorf("accept with the PIN = %q", got)
+	}
+	if off := c.Tamper.Offset(); off != 0 {
+		t.Errorf("Offset() = %v after accepting, want 0", off)
+	}
+}
@@ -0,0 +1,80 @@
+package remote
+
+import (
+	"strings"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/scheduler"
+)
+
+// wakeCommand handles "wake [status]", "wake at <HH:MM> [days]" and "wake off [days]"
+func (c *Controller) wakeCommand(args []string) string {
+	if c.Wake == nil {
+		return i18n.T("wake_unavailable")
+	}
+	if len(args) == 0 || args[0] == "status" {
+		return c.wakeStatus()
+	}
+
+	switch args[0] {
+	case "at":
+		if len(args) < 2 {
+			return i18n.T("wake_usage")
+		}
+		at, err := scheduler.ParseTimeOfDay(args[1])
+		if err != nil || at.Solar != "" {
+			return i18n.T("wake_usage")
+		}
+		days, ok := wakeDays(args[2:])
+		if !ok {
+			return i18n.T("wake_usage")
+		}
+		c.Config.Update(func(s *scheduler.Settings) {
+			s.SetWake(days, at)
+		})
+		return i18n.T("wake_set", at, days) + "\n" + c.wakeStatus()
+
+	case "off":
+		days, ok := wakeDays(args[1:])
+		if !ok {
+			return i18n.T("wake_usage")
+		}
+		c.Config.Update(func(s *scheduler.Settings) {
+			s.ClearWake(days)
+		})
+		return i18n.T("wake_removed", days) + "\n" + c.wakeStatus()
+	}
+	return i18n.T("wake_usage")
+}
+
+// wakeDays parses the optional days argument, every day if it is missing
+func wakeDays(args []string) (scheduler.Weekdays, bool) {
+	if len(args) == 0 {
+		return nil, true
+	}
+	days, err := scheduler.ParseWeekdays(strings.Join(args, ","))
+	return days, err == nil
+}
+
+// wakeStatus shows the wake times, the next one and what the timer is armed for
+func (c *Controller) wakeStatus() string {
+	cfg := c.Config.Get()
+	if len(cfg.Wake) == 0 {
+		return i18n.T("wake_none")
+	}
+	var lines []string
+	for _, w := range cfg.Wake {
+		lines = append(lines, i18n.T("wake_status", w.Days, w.At))
+	}
+	if next := cfg.NextWake(c.now()); !next.IsZero() {
+		lines = append(lines, i18n.T("wake_next", next.Format("2006-01-02 15:04")))
+	}
+	if armed, ok, err := c.Wake.Armed(); err != nil {
+		lines = append(lines, i18n.T("wake_armed_unknown", err))
+	} else if ok {
+		lines = append(lines, i18n.T("wake_armed", armed.In(cfg.Location()).Format("2006-01-02 15:04")))
+	} else {
+		lines = append(lines, i18n.T("wake_not_armed"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/activity"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// PostponeRecheck is how long a postponed operation waits before it is checked again
+const PostponeRecheck = time.Minute
+
+// validateBusy checks the activity guard thresholds
+func (s *Settings) validateBusy() error {
+	if !s.Busy.Enabled() {
+		return nil
+	}
+	if s.Busy.MaxPostponeMinutes <= 0 {
+		return fmt.Errorf("busy: max_postpone_minutes must be positive")
+	}
+	if s.Busy.CPUPercent < 0 || s.Busy.CPUPercent > 100 || s.Busy.DiskKBps < 0 || s.Busy.NetKBps < 0 {
+		return fmt.Errorf("busy: invalid threshold")
+	}
+	return s.Busy.Validate()
+}
+
+// postpone asks the activity guard whether an operation that has been due since due has to wait.
+// Every decision is logged and kept by the guard for status.
+func (s *Scheduler) postpone(now time.Time, cfg Settings, mode, reason string, due time.Time) bool {
+	if s.Busy == nil || !cfg.Busy.Enabled() {
+		return false
+	}
+
+	d := s.Busy.Check(now, cfg.Busy, mode, reason, now.Sub(due))
+	name := power.OperationName(mode)
+	switch d.Verdict {
+	case activity.Postponed:
+		log.Printf(i18n.T("log_busy_postponed", name, d.Load, d.Exceeded, int(PostponeRecheck.Minutes())))
+	case activity.LimitHit:
+		log.Printf(i18n.T("log_busy_limit", name, d.Load, cfg.Busy.MaxPostponeMinutes))
+	case activity.NoData:
+		log.Printf(i18n.T("log_busy_no_data", name, d.Exceeded))
+	default:
+		log.Printf(i18n.T("log_busy_proceed", name, d.Load))
+	}
+	return d.Verdict == activity.Postponed
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/alarm"
+	"codans.com/autoshut/src/tamper"
+)
+
+// Clock is the time source of the scheduler loop
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+	// Alarm returns a channel that receives once the wall clock reaches t, or earlier
+	// when the wall clock is set or the machine resumes; the zero t waits for those only.
+	// stop releases the alarm.
+	Alarm(t time.Time) (c <-chan time.Time, stop func())
+	// Monotonic returns the time since boot, which moves on during suspend
+	// but not when the wall clock is set
+	Monotonic() time.Duration
+	// Suspended returns the time spent in sleep or hibernation since boot
+	Suspended() time.Duration
+}
+
+// MinSuspend is the shortest growth of Suspended counted as a resume; smaller
+// differences are rounding between the two underlying clocks
+const MinSuspend = time.Second
+
+// Rand is the random source used for the 1-10 minute delay, satisfied by *rand.Rand
+type Rand interface {
+	Intn(n int) int
+}
+
+// RealClock is the system wall clock
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (RealClock) Alarm(t time.Time) (<-chan time.Time, func()) {
+	return alarm.At(t)
+}
+
+func (RealClock) Monotonic() time.Duration {
+	return tamper.Uptime()
+}
+
+func (RealClock) Suspended() time.Duration {
+	return tamper.Suspended()
+}
+
+// poweredOn reports whether this check is the first since the service started, i.e. a boot,
+// or the machine was suspended since the previous check, i.e. a resume
+func (s *Scheduler) poweredOn() bool {
+	suspended := s.Clock.Suspended()
+	first := !s.ticked
+	resumed := s.ticked && suspended-s.suspended >= MinSuspend
+	s.ticked, s.suspended = true, suspended
+	return first || resumed
+}
+
+// FakeClock is a manually advanced Clock for tests.
+// Advance moves both the wall clock and the monotonic clock, Set only the wall clock,
+// Suspend all of them as if the machine slept for d. Set and Suspend also fire every alarm.
+type FakeClock struct {
+	mu        sync.Mutex
+	now       time.Time
+	mono      time.Duration
+	suspended time.Duration
+	waiters   []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at    time.Time
+	ch    chan time.Time
+	alarm bool
+}
+
+// NewFakeClock returns a FakeClock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *FakeClock) Alarm(t time.Time) (<-chan time.Time, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if !t.IsZero() && !t.After(c.now) {
+		ch <- c.now
+		return ch, func() {}
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: t, ch: ch, alarm: true})
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, w := range c.waiters {
+			if w.ch == ch {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (c *FakeClock) Monotonic() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mono
+}
+
+func (c *FakeClock) Suspended() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.suspended
+}
+
+// Advance moves the clock forward by d and fires every timer that became due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mono += d
+	c.set(c.now.Add(d), false)
+}
+
+// Suspend moves the clock forward by d spent in sleep and fires every timer that
+// became due and every alarm
+func (c *FakeClock) Suspend(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mono += d
+	c.suspended += d
+	c.set(c.now.Add(d), true)
+}
+
+// Set moves the wall clock to t, which may also be in the past, and fires every
+// timer that became due and every alarm
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(t, true)
+}
+
+func (c *FakeClock) set(t time.Time, jumped bool) {
+	c.now = t
+	sort.Slice(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		due := !w.at.IsZero() && !w.at.After(t)
+		if due || (jumped && w.alarm) {
+			w.ch <- t
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of timers that have not fired yet
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// SequenceRand is a Rand that returns fixed values in order, for tests.
+// Each value is clamped to the requested range; once exhausted it returns 0.
+type SequenceRand struct {
+	Values []int
+}
+
+func (r *SequenceRand) Intn(n int) int {
+	if len(r.Values) == 0 {
+		return 0
+	}
+	v := r.Values[0]
+	r.Values = r.Values[1:]
+	if v >= n {
+		v = n - 1
+	}
+	if v < 0 {
+		v = 0
+	}
+	return v
+}
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/tamper"
+)
+
+// checkClock compares the wall clock with the monotonic clock and applies the
+// configured response to a clock change. It returns the time the schedule is
+// evaluated at: the trusted time with the "enforce" response, otherwise now.
+func (s *Scheduler) checkClock(now time.Time, cfg Settings) time.Time {
+	if s.Tamper == nil || !cfg.Tamper.Enabled() {
+		return now
+	}
+
+	if e, changed := s.Tamper.Observe(now, s.Clock.Monotonic(), cfg.Tamper.Threshold()); changed {
+		log.Printf(i18n.T("log_tamper_detected", e.Jump, e.Wall.Format("2006-01-02 15:04:05"),
+			e.Trusted.Format("2006-01-02 15:04:05"), cfg.Tamper.Response))
+		if cfg.Tamper.Response == tamper.ResponseOperate {
+			s.PerformOperation(cfg.Mode, "tamper", now)
+		}
+	}
+
+	if cfg.Tamper.Response == tamper.ResponseEnforce {
+		return s.Tamper.Trusted(now)
+	}
+	return now
+}
@@ -0,0 +1,232 @@
+// Package scheduler decides when the automatic operation runs
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/activity"
+	"codans.com/autoshut/src/calendar"
+	"codans.com/autoshut/src/extension"
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/lockout"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/process"
+	"codans.com/autoshut/src/quota"
+	"codans.com/autoshut/src/tamper"
+)
+
+// Settings holds the schedule, operation mode and warning configuration
+type Settings struct {
+	Mode           string `json:"mode"`            // Operation mode, one of power.Modes
+	ShowWarning    bool   `json:"warning"`         // Whether to show warning before shutdown/hibernate
+	WarningMinutes int    `json:"warning_minutes"` // Minutes to warn before shutdown/hibernate
+
+	// IANA time zone the windows, cron triggers and quota days are evaluated in, e.g.
+	// "Europe/Berlin"; empty uses the system zone
+	TimeZone string         `json:"timezone,omitempty"`
+	location *time.Location // Loaded from TimeZone by Validate
+
+	// Position of the machine, needed for sunrise and sunset based windows
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+
+	// Automatic shutdown time windows, evaluated in order
+	Windows []Window `json:"windows"`
+
+	// Exact triggers given as cron expressions, independent of the windows
+	Cron []CronTrigger `json:"cron,omitempty"`
+
+	// Times at which the machine is woken up, e.g. for morning tasks
+	Wake []WakeTime `json:"wake,omitempty"`
+
+	// Operations that run after the user has been idle for a while
+	Idle []IdlePolicy `json:"idle,omitempty"`
+
+	// Thresholds that postpone a due operation while the machine is busy
+	Busy activity.Config `json:"busy,omitempty"`
+
+	// Processes that defer the operation, and processes terminated inside the windows
+	Processes process.Config `json:"processes,omitempty"`
+
+	// Commands run before the operation and after resume or boot
+	Hooks hook.Config `json:"hooks,omitempty"`
+
+	// Operations tried in turn while the power state does not change, e.g. hibernate,
+	// suspend, shutdown, force-shutdown; empty falls back from hibernate to shutdown
+	Escalation []EscalationStep `json:"escalation,omitempty"`
+
+	// Snoozes the user may ask for when the warning appears
+	Extensions extension.Config `json:"extensions,omitempty"`
+
+	// Fixed grace instead of a new random delay when the machine is powered back on
+	// inside a window whose operation already ran
+	Lockout lockout.Config `json:"lockout,omitempty"`
+
+	// Detection of system clock changes
+	Tamper tamper.Config `json:"tamper,omitempty"`
+
+	// Daily screen-time quota
+	Quota quota.Config `json:"quota,omitempty"`
+
+	// ICS files with holidays and other exceptions, consulted before the windows
+	Calendars []calendar.Source  `json:"calendars,omitempty"`
+	Calendar  *calendar.Calendar `json:"-"`
+}
+
+// DefaultSettings returns the built-in configuration
+func DefaultSettings() Settings {
+	return Settings{
+		Mode:           "hibernate",
+		ShowWarning:    true,
+		WarningMinutes: 5,
+		Windows: []Window{
+			{Name: "default", Start: TimeOfDay{Hour: 22, Minute: 0}, End: TimeOfDay{Hour: 23, Minute: 59}},
+		},
+	}
+}
+
+// LoadFile reads the JSON configuration file at path on top of s.
+// Fields missing from the file keep their current value.
+func LoadFile(path string, s *Settings) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	windows := s.Windows
+	s.Windows = nil
+	if err := json.Unmarshal(data, s); err != nil {
+		s.Windows = windows
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if s.Windows == nil {
+		s.Windows = windows
+	}
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	return s.LoadCalendars(filepath.Dir(path))
+}
+
+// Validate checks the windows and names those that have none
+func (s *Settings) Validate() error {
+	if !power.ValidMode(s.Mode) {
+		return fmt.Errorf("invalid operation mode %q", s.Mode)
+	}
+	seen := map[string]bool{}
+	for i := range s.Windows {
+		w := &s.Windows[i]
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("window%d", i+1)
+		}
+		if seen[strings.ToLower(w.Name)] {
+			return fmt.Errorf("duplicate window name %q", w.Name)
+		}
+		seen[strings.ToLower(w.Name)] = true
+		if w.Mode != "" && !power.ValidMode(w.Mode) {
+			return fmt.Errorf("window %s: invalid operation mode %q", w.Name, w.Mode)
+		}
+		if w.ForceAfterMinutes < 0 {
+			return fmt.Errorf("window %s: force_after_minutes must not be negative", w.Name)
+		}
+	}
+	if s.Quota.Mode != "" && !power.ValidMode(s.Quota.Mode) {
+		return fmt.Errorf("quota: invalid operation mode %q", s.Quota.Mode)
+	}
+	if err := s.validateSolar(); err != nil {
+		return err
+	}
+	if err := s.validateTimeZone(); err != nil {
+		return err
+	}
+	if err := s.Tamper.Validate(); err != nil {
+		return err
+	}
+	if err := s.Lockout.Validate(); err != nil {
+		return err
+	}
+	if err := s.validateProcesses(); err != nil {
+		return err
+	}
+	if err := s.validateBusy(); err != nil {
+		return err
+	}
+	if err := s.validateExtensions(); err != nil {
+		return err
+	}
+	if err := s.Hooks.Validate(); err != nil {
+		return err
+	}
+	if err := s.validateIdle(); err != nil {
+		return err
+	}
+	if err := s.validateWake(); err != nil {
+		return err
+	}
+	if err := s.validateEscalation(); err != nil {
+		return err
+	}
+	return s.validateCron()
+}
+
+// FindWindow returns the index of the window called name (case-insensitive), or -1
+func (s *Settings) FindWindow(name string) int {
+	for i, w := range s.Windows {
+		if strings.EqualFold(w.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Config guards Settings shared between the scheduler loop and remote commands
+type Config struct {
+	mu       sync.Mutex // Mutex for protecting time settings
+	settings Settings
+	changed  chan struct{}
+}
+
+// NewConfig creates a Config with the given initial settings
+func NewConfig(s Settings) *Config {
+	return &Config{settings: s, changed: make(chan struct{}, 1)}
+}
+
+// Get returns a copy of the current settings
+func (c *Config) Get() Settings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.settings
+	s.Windows = append([]Window(nil), c.settings.Windows...)
+	s.Cron = append([]CronTrigger(nil), c.settings.Cron...)
+	s.Idle = append([]IdlePolicy(nil), c.settings.Idle...)
+	s.Wake = append([]WakeTime(nil), c.settings.Wake...)
+	s.Escalation = append([]EscalationStep(nil), c.settings.Escalation...)
+	return s
+}
+
+// Update modifies the settings while holding the lock
+func (c *Config) Update(fn func(s *Settings)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&c.settings)
+	c.Notify()
+}
+
+// Notify wakes the scheduler loop to evaluate the schedule again, after a change
+// of the settings or of state outside them (queue, extensions, quota)
+func (c *Config) Notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Changed receives a value after Notify
+func (c *Config) Changed() <-chan struct{} {
+	return c.changed
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/cron"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// CronMissedGrace is how late a cron trigger may still fire, e.g. after resume.
+// Older occurrences are skipped so a machine woken at 08:00 doesn't reboot for 04:30.
+const CronMissedGrace = 10 * time.Minute
+
+// CronTrigger runs an operation at the times given by a cron expression
+type CronTrigger struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"` // 5-field expression or @daily-style macro
+	Mode     string `json:"mode,omitempty"`
+
+	parsed *cron.Schedule
+}
+
+// Next returns the first trigger time after t
+func (c CronTrigger) Next(t time.Time) time.Time {
+	if c.parsed == nil {
+		return time.Time{}
+	}
+	return c.parsed.Next(t)
+}
+
+// ModeOr returns the trigger's operation mode, or fallback if it has none
+func (c CronTrigger) ModeOr(fallback string) string {
+	if c.Mode != "" {
+		return c.Mode
+	}
+	return fallback
+}
+
+// validateCron parses every cron expression and names triggers that have none
+func (s *Settings) validateCron() error {
+	seen := map[string]bool{}
+	for i := range s.Cron {
+		c := &s.Cron[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("cron%d", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate cron trigger name %q", c.Name)
+		}
+		seen[c.Name] = true
+		parsed, err := cron.Parse(c.Schedule)
+		if err != nil {
+			return err
+		}
+		c.parsed = parsed
+		if c.Mode != "" && !power.ValidMode(c.Mode) {
+			return fmt.Errorf("cron %s: invalid operation mode %q", c.Name, c.Mode)
+		}
+	}
+	return nil
+}
+
+// cronState tracks the pending occurrence of one trigger
+type cronState struct {
+	schedule string
+	next     time.Time
+	warned   bool
+}
+
+// tickCron fires due cron triggers through the same warning path as the windows
+func (s *Scheduler) tickCron(now time.Time, cfg Settings) {
+	if s.cron == nil {
+		s.cron = map[string]*cronState{}
+	}
+
+	active := map[string]bool{}
+	for _, trigger := range cfg.Cron {
+		active[trigger.Name] = true
+		st := s.cron[trigger.Name]
+		if st == nil || st.schedule != trigger.Schedule {
+			st = &cronState{schedule: trigger.Schedule, next: trigger.Next(now)}
+			s.cron[trigger.Name] = st
+			applog.Debugf("定时任务 %s (%s) 下次执行时间: %s", trigger.Name, trigger.Schedule, st.next.Format("2006-01-02 15:04"))
+		}
+		if st.next.IsZero() {
+			continue
+		}
+
+		mode := trigger.ModeOr(cfg.Mode)
+
+		// 错过太久的触发（例如休眠期间）直接跳过
+		if now.Sub(st.next) > CronMissedGrace {
+			log.Printf(i18n.T("log_cron_missed", trigger.Name, st.next.Format("2006-01-02 15:04")))
+			st.next = trigger.Next(now)
+			st.warned = false
+			continue
+		}
+
+		// 到达警告时间
+		warningTime := st.next.Add(-time.Duration(cfg.WarningMinutes) * time.Minute)
+		if cfg.ShowWarning && !st.warned && !now.Before(warningTime) && now.Before(st.next) {
+			remainMinutes := int(st.next.Sub(now).Minutes())
+			if remainMinutes < 1 {
+				remainMinutes = 1
+			}
+			st.warned = true
+			if !s.Warn(mode, remainMinutes) {
+				log.Printf(i18n.T("shutdown_cancelled", power.OperationName(mode)))
+				st.next = trigger.Next(st.next)
+				st.warned = false
+				continue
+			}
+		}
+
+		// 到达触发时间
+		if !now.Before(st.next) {
+			log.Printf(i18n.T("log_cron_fired", trigger.Name, trigger.Schedule, power.OperationName(mode)))
+			s.PerformOperation(mode, "cron "+trigger.Name, st.next)
+			st.next = trigger.Next(now)
+			st.warned = false
+		}
+
+		if cfg.ShowWarning && !st.warned {
+			s.plan(now, st.next.Add(-time.Duration(cfg.WarningMinutes)*time.Minute))
+		}
+		s.plan(now, st.next)
+	}
+
+	// 删除已从配置中移除的定时任务
+	for name := range s.cron {
+		if !active[name] {
+			delete(s.cron, name)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/history"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// DefaultVerify is how long a step has to change the power state if verify_seconds is not set
+const DefaultVerify = 2 * time.Minute
+
+// RetryInterval is how often a graceful shutdown refused by an inhibitor is asked for
+// again while a window's force_after_minutes have not passed
+const RetryInterval = time.Minute
+
+// DefaultEscalation is used if no chain is configured: a hibernation that fails shuts the machine down
+var DefaultEscalation = []EscalationStep{{Mode: "hibernate"}, {Mode: "shutdown"}}
+
+// EscalationStep is one operation of the escalation chain
+type EscalationStep struct {
+	Mode          string `json:"mode"`
+	VerifySeconds int    `json:"verify_seconds,omitempty"`
+
+	retry bool // Repeat the step if it is refused, until VerifySeconds have passed
+}
+
+// Verify returns how long the step has to change the power state
+func (e EscalationStep) Verify() time.Duration {
+	if e.VerifySeconds <= 0 {
+		return DefaultVerify
+	}
+	return time.Duration(e.VerifySeconds) * time.Second
+}
+
+// validateEscalation checks the escalation chain
+func (s *Settings) validateEscalation() error {
+	seen := map[string]bool{}
+	for i, e := range s.Escalation {
+		if !power.ValidStep(e.Mode) {
+			return fmt.Errorf("escalation step %d: unknown mode %q", i+1, e.Mode)
+		}
+		if seen[e.Mode] {
+			return fmt.Errorf("escalation step %d: mode %q appears twice", i+1, e.Mode)
+		}
+		seen[e.Mode] = true
+		if e.VerifySeconds < 0 {
+			return fmt.Errorf("escalation step %d: verify_seconds must not be negative", i+1)
+		}
+	}
+	return nil
+}
+
+// EscalationFor returns the steps tried for mode: the chain from the step with this
+// mode on. If the chain does not contain mode it returns mode alone and false,
+// the operation then runs once without verification.
+func (s *Settings) EscalationFor(mode string) ([]EscalationStep, bool) {
+	chain := s.Escalation
+	if len(chain) == 0 {
+		chain = DefaultEscalation
+	}
+	for i, e := range chain {
+		if e.Mode == mode {
+			return chain[i:], true
+		}
+	}
+	return []EscalationStep{{Mode: mode}}, false
+}
+
+// forceAfter makes the first graceful shutdown or reboot in steps the last graceful
+// step, for a window with force_after_minutes: it is asked for again while refused,
+// and if the machine is still on after the given time the forced variant follows.
+// It reports false if steps contain no graceful shutdown or reboot.
+func forceAfter(steps []EscalationStep, after time.Duration) ([]EscalationStep, bool) {
+	for i, e := range steps {
+		if e.Mode != "shutdown" && e.Mode != "reboot" {
+			continue
+		}
+		e.VerifySeconds = int(after / time.Second)
+		e.retry = true
+		forced := append([]EscalationStep(nil), steps[:i]...)
+		return append(forced, e, EscalationStep{Mode: "force-" + e.Mode}), true
+	}
+	return steps, false
+}
+
+// escalation is the operation whose current step waits for the power state to change.
+// The loop checks it on resume and when the verification time or a retry is due.
+type escalation struct {
+	id      int
+	mode    string
+	reason  string
+	steps   []EscalationStep
+	chained bool
+	step    int
+
+	at        time.Duration // 本步骤执行时开机以来的时间
+	suspended time.Duration // 本步骤执行时系统挂起过的总时间
+	tried     time.Duration // 上次请求本步骤的时间
+	err       error         // 上次请求返回的错误，带重试的步骤被拒绝时不为空
+	blockers  map[string]bool
+}
+
+// escalate performs mode and, while the power state does not change, the following
+// steps of the escalation chain. With force set, a graceful shutdown or reboot is
+// forced once that time has passed. Every step is recorded in the history.
+// A step that has to be verified is left pending for tickEscalation, so the loop keeps
+// running meanwhile. It returns the error of the last step if every step was refused.
+func (s *Scheduler) escalate(mode, reason string, cfg Settings, force time.Duration) error {
+	// 新的操作取代仍在等待确认的操作
+	if e := s.pending; e != nil {
+		s.pending = nil
+		s.historyFinish(e.id, history.Unconfirmed, e.err)
+	}
+
+	steps, chained := cfg.EscalationFor(mode)
+	if force > 0 {
+		var forced bool
+		steps, forced = forceAfter(steps, force)
+		chained = chained || forced
+	}
+	e := &escalation{id: s.historyBegin(mode, reason), mode: mode, reason: reason, steps: steps, chained: chained}
+	return s.runStep(e)
+}
+
+// runStep performs the current step of e and, while steps are refused, the following ones.
+// Sleep modes, shutdown and reboot are then left pending; other modes are confirmed right away.
+func (s *Scheduler) runStep(e *escalation) error {
+	for {
+		step := e.steps[e.step]
+		s.historyStep(e.id, step.Mode)
+		e.at, e.suspended = s.Clock.Monotonic(), s.Clock.Suspended()
+		e.tried = e.at
+		e.blockers = map[string]bool{}
+		e.err = s.Backend.Perform(power.Operation{Mode: step.Mode, Reason: e.reason})
+
+		if e.err != nil && !step.retry {
+			s.historyFinish(e.id, history.Failed, e.err)
+			if !s.nextStep(e) {
+				return e.err
+			}
+			continue
+		}
+		if !e.chained {
+			s.historyFinish(e.id, history.Started, e.err)
+			return nil
+		}
+		if _, dryRun := s.Backend.(power.Recorder); dryRun || !power.Suspends(step.Mode) && !power.Halts(step.Mode) {
+			s.historyFinish(e.id, history.Confirmed, e.err)
+			return nil
+		}
+		// 等待电源状态改变，由 tickEscalation 检查
+		s.pending = e
+		s.reportInhibitors(e.id, step.Mode, e.blockers)
+		due := step.Verify()
+		if e.err != nil && RetryInterval < due {
+			due = RetryInterval
+		}
+		s.plan(s.evaluated, s.evaluated.Add(due))
+		return nil
+	}
+}
+
+// nextStep moves e to the following step and logs why. It reports false, after
+// logging the end of the chain, if the current step was the last one.
+func (s *Scheduler) nextStep(e *escalation) bool {
+	step := e.steps[e.step]
+	if e.step == len(e.steps)-1 {
+		if len(e.steps) > 1 {
+			log.Printf(i18n.T("log_escalation_end", power.OperationName(step.Mode)))
+		}
+		return false
+	}
+	next := power.OperationName(e.steps[e.step+1].Mode)
+	if e.err != nil {
+		log.Printf(i18n.T("log_escalation_failed", power.OperationName(step.Mode), e.err, next))
+	} else {
+		log.Printf(i18n.T("log_escalation_timeout", power.OperationName(step.Mode), step.Verify(), next))
+	}
+	e.step++
+	return true
+}
+
+// tickEscalation verifies the pending step. A sleep mode is confirmed once the machine
+// has resumed; after shutdown and reboot the service is expected to be stopped in
+// time. Once the verification time has passed the next step follows, and a refused
+// step with retry is requested again every RetryInterval until then. Applications
+// holding the step back are logged and added to the history.
+// It reports whether a step is still pending.
+func (s *Scheduler) tickEscalation(now time.Time) bool {
+	e := s.pending
+	if e == nil {
+		return false
+	}
+	step := e.steps[e.step]
+	if e.err == nil && power.Suspends(step.Mode) && s.Clock.Suspended()-e.suspended >= MinSuspend {
+		s.pending = nil
+		s.historyFinish(e.id, history.Confirmed, nil)
+		return false
+	}
+
+	if s.Clock.Monotonic()-e.at >= step.Verify() {
+		s.pending = nil
+		if e.err != nil {
+			s.historyFinish(e.id, history.Failed, e.err)
+		} else {
+			s.historyFinish(e.id, history.Unconfirmed, nil)
+		}
+		if !s.nextStep(e) {
+			if e.err != nil {
+				s.operationFailed(e.mode, e.err)
+			}
+			return false
+		}
+		if err := s.runStep(e); err != nil {
+			s.operationFailed(e.mode, err)
+		}
+		return s.pending != nil
+	}
+
+	s.reportInhibitors(e.id, step.Mode, e.blockers)
+	if e.err != nil && s.Clock.Monotonic()-e.tried >= RetryInterval {
+		e.tried = s.Clock.Monotonic()
+		e.err = s.Backend.Perform(power.Operation{Mode: step.Mode, Reason: e.reason})
+	}
+
+	// 验证时间结束或下次重试时再检查，从睡眠恢复时循环也会被唤醒
+	due := e.at + step.Verify()
+	if e.err != nil && e.tried+RetryInterval < due {
+		due = e.tried + RetryInterval
+	}
+	s.plan(now, now.Add(due-s.Clock.Monotonic()))
+	return true
+}
+
+// stopEscalation records that the service stopped while a step was pending,
+// usually because of the shutdown itself
+func (s *Scheduler) stopEscalation() {
+	if e := s.pending; e != nil {
+		s.pending = nil
+		s.historyFinish(e.id, history.Stopped, e.err)
+	}
+}
+
+// reportInhibitors logs the applications holding back mode that are not in seen yet.
+// Forced steps ignore them.
+func (s *Scheduler) reportInhibitors(id int, mode string, seen map[string]bool) {
+	inhibited, ok := s.Backend.(power.Inhibited)
+	if !ok || strings.HasPrefix(mode, "force-") {
+		return
+	}
+	inhibitors, err := inhibited.Inhibitors(mode)
+	if err != nil {
+		applog.Debugf("无法获取阻止%s的应用程序: %v", power.OperationName(mode), err)
+		return
+	}
+	for _, in := range inhibitors {
+		who := in.String()
+		if seen[who] {
+			continue
+		}
+		seen[who] = true
+		log.Printf(i18n.T("log_inhibited", power.OperationName(mode), who))
+		if s.History != nil {
+			if err := s.History.Blocked(id, who); err != nil {
+				log.Printf(i18n.T("log_history_failed", err))
+			}
+		}
+	}
+}
+
+// historyBegin records a new operation and returns its ID, 0 without a history
+func (s *Scheduler) historyBegin(mode, reason string) int {
+	if s.History == nil {
+		return 0
+	}
+	id, err := s.History.Begin(s.Clock.Now(), mode, reason)
+	if err != nil {
+		log.Printf(i18n.T("log_history_failed", err))
+	}
+	return id
+}
+
+func (s *Scheduler) historyStep(id int, mode string) {
+	if s.History == nil {
+		return
+	}
+	if err := s.History.Step(id, mode, s.Clock.Now()); err != nil {
+		log.Printf(i18n.T("log_history_failed", err))
+	}
+}
+
+func (s *Scheduler) historyFinish(id int, result history.Result, stepErr error) {
+	if s.History == nil {
+		return
+	}
+	if err := s.History.Finish(id, result, stepErr); err != nil {
+		log.Printf(i18n.T("log_history_failed", err))
+	}
+}
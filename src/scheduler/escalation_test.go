@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/history"
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/power"
+)
+
+// refusingBackend refuses the modes in refuse and records every request
+type refusingBackend struct {
+	refuse    map[string]bool
+	performed []string
+}
+
+func (b *refusingBackend) Perform(op power.Operation) error {
+	b.performed = append(b.performed, op.Mode)
+	if b.refuse[op.Mode] {
+		return errors.New("refused")
+	}
+	return nil
+}
+
+// newEscalationScheduler returns a scheduler whose hibernation at 22:01 escalates to shutdown
+func newEscalationScheduler(t *testing.T, backend power.Backend) (*Scheduler, *FakeClock, *history.Log) {
+	t.Helper()
+	cfg := nightSettings(t, "hibernate", false)
+	cfg.Escalation = []EscalationStep{{Mode: "hibernate", VerifySeconds: 120}, {Mode: "shutdown", VerifySeconds: 60}}
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, _ := newTestScheduler(cfg, start, 0, 0)
+	s.Backend = backend
+	log, err := history.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.History = log
+	s.Tick()
+	clock.Advance(time.Minute)
+	s.Tick()
+	return s, clock, log
+}
+
+// results returns the modes and results of the steps of the only history entry
+func results(t *testing.T, log *history.Log) []string {
+	t.Helper()
+	entries := log.List()
+	if len(entries) != 1 {
+		t.Fatalf("history has %d entries, want 1", len(entries))
+	}
+	var steps []string
+	for _, st := range entries[0].Steps {
+		steps = append(steps, st.Mode+" "+string(st.Result))
+	}
+	return steps
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEscalationConfirmedOnResume(t *testing.T) {
+	backend := &refusingBackend{}
+	s, clock, log := newEscalationScheduler(t, backend)
+
+	// 步骤等待确认时 Tick 立即返回，循环在验证时间结束时再检查
+	if want := clock.Now().Add(2 * time.Minute); !s.wakeAt().Equal(want) {
+		t.Errorf("wakeAt() = %v, want the end of the verification %v", s.wakeAt(), want)
+	}
+	if got := results(t, log); !equal(got, []string{"hibernate started"}) {
+		t.Errorf("steps = %v while waiting", got)
+	}
+
+	clock.Suspend(8 * time.Hour)
+	s.Tick()
+	if got := results(t, log); !equal(got, []string{"hibernate confirmed"}) {
+		t.Errorf("steps = %v after resuming", got)
+	}
+	if !equal(backend.performed, []string{"hibernate"}) {
+		t.Errorf("performed %v, want only the hibernation", backend.performed)
+	}
+}
+
+func TestEscalationTimeout(t *testing.T) {
+	backend := &refusingBackend{}
+	s, clock, log := newEscalationScheduler(t, backend)
+
+	// 到达验证时间前没有恢复，执行下一步
+	clock.Advance(time.Minute)
+	s.Tick()
+	if len(backend.performed) != 1 {
+		t.Fatalf("performed %v before the verification time", backend.performed)
+	}
+	clock.Advance(time.Minute)
+	s.Tick()
+	if got := results(t, log); !equal(got, []string{"hibernate unconfirmed", "shutdown started"}) {
+		t.Errorf("steps = %v after the verification time", got)
+	}
+
+	// 服务在关机过程中停止
+	s.stopEscalation()
+	if got := results(t, log); !equal(got, []string{"hibernate unconfirmed", "shutdown stopped"}) {
+		t.Errorf("steps = %v after stopping", got)
+	}
+}
+
+func TestEscalationRefused(t *testing.T) {
+	backend := &refusingBackend{refuse: map[string]bool{"hibernate": true}}
+	s, _, log := newEscalationScheduler(t, backend)
+
+	// 被拒绝的步骤立即进入下一步
+	if got := results(t, log); !equal(got, []string{"hibernate failed", "shutdown started"}) {
+		t.Errorf("steps = %v", got)
+	}
+	if s.pending == nil {
+		t.Error("shutdown is not waiting for confirmation")
+	}
+}
+
+func TestPerformRunsInLoop(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	cfg := nightSettings(t, "shutdown", false)
+	cfg.Hooks.Before = []hook.Hook{{Name: "never", Command: []string{"autoshut-test-missing-command"}, OnFailure: hook.Abort}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s, clock, backend := newTestScheduler(cfg, start)
+	log, err := history.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.History = log
+	done := make(chan struct{})
+	go func() {
+		s.Run()
+		close(done)
+	}()
+
+	// before 钩子同样适用于远程操作
+	if err := s.Perform("shutdown", "remote"); err == nil {
+		t.Error("Perform() succeeded although the before hook aborted it")
+	}
+	s.Config.Update(func(c *Settings) { c.Hooks.Before = nil })
+	if err := s.Perform("shutdown", "remote"); err != nil {
+		t.Errorf("Perform() = %v", err)
+	}
+	s.Stop()
+	<-done
+
+	records := backend.Records()
+	if len(records) != 1 || records[0].Mode != "shutdown" || records[0].Reason != "remote" || !records[0].Time.Equal(clock.Now()) {
+		t.Errorf("Records() = %+v, want one remote shutdown", records)
+	}
+	if entries := log.List(); len(entries) != 1 || entries[0].Reason != "remote" {
+		t.Errorf("history = %+v, want the remote shutdown", entries)
+	}
+	if err := s.Perform("shutdown", "remote"); err == nil {
+		t.Error("Perform() succeeded after Stop")
+	}
+}
+
+func TestEscalationRetryThenForce(t *testing.T) {
+	cfg := nightSettings(t, "shutdown", false)
+	cfg.Windows[0].ForceAfterMinutes = 3
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, _ := newTestScheduler(cfg, start, 0, 0)
+	backend := &refusingBackend{refuse: map[string]bool{"shutdown": true}}
+	s.Backend = backend
+
+	s.Tick()
+	clock.Advance(time.Minute)
+	s.Tick()
+	// 被拒绝的正常关机每分钟重试一次，不阻塞循环
+	if want := clock.Now().Add(RetryInterval); !s.wakeAt().Equal(want) {
+		t.Errorf("wakeAt() = %v, want the retry at %v", s.wakeAt(), want)
+	}
+	for i := 0; i < 3; i++ {
+		clock.Advance(RetryInterval)
+		s.Tick()
+	}
+	want := []string{"shutdown", "shutdown", "shutdown", "force-shutdown"}
+	if !equal(backend.performed, want) {
+		t.Errorf("performed %v, want %v", backend.performed, want)
+	}
+}
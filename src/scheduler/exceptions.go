@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/calendar"
+	"codans.com/autoshut/src/power"
+)
+
+// LoadCalendars reads the configured ICS files into s.Calendar.
+// Relative paths are resolved against baseDir; event times are read in s.Location(),
+// so Validate has to run first.
+func (s *Settings) LoadCalendars(baseDir string) error {
+	sources := make([]calendar.Source, len(s.Calendars))
+	for i, src := range s.Calendars {
+		if src.Action != "" {
+			if _, err := calendar.ParseAction(string(src.Action)); err != nil {
+				return err
+			}
+		}
+		if !filepath.IsAbs(src.Path) && baseDir != "" {
+			src.Path = filepath.Join(baseDir, src.Path)
+		}
+		sources[i] = src
+	}
+
+	cal, err := calendar.Load(sources, s.Location())
+	if err != nil {
+		return err
+	}
+	for _, e := range cal.Exceptions {
+		if e.Action != calendar.ActionWindow {
+			continue
+		}
+		if _, _, err := ParseRange(e.Window); err != nil {
+			return fmt.Errorf("%s: event %q: %v", e.Source, e.Summary, err)
+		}
+		if e.Mode != "" && !power.ValidMode(e.Mode) {
+			return fmt.Errorf("%s: event %q: invalid operation mode %q", e.Source, e.Summary, e.Mode)
+		}
+	}
+	s.Calendar = cal
+	return nil
+}
+
+// windowsForDay returns the windows that may start on day and the weekday
+// their Days are matched against, after applying the exception calendar
+// and resolving sunrise and sunset times
+func (s *Settings) windowsForDay(day time.Time) ([]Window, time.Weekday) {
+	windows, weekday := s.calendarWindows(day)
+	return s.solarWindows(windows, day), weekday
+}
+
+// calendarWindows applies the exception calendar to the windows of day
+func (s *Settings) calendarWindows(day time.Time) ([]Window, time.Weekday) {
+	e, ok := s.Calendar.Lookup(day)
+	if !ok {
+		return s.Windows, day.Weekday()
+	}
+
+	switch e.Action {
+	case calendar.ActionSkip:
+		return nil, day.Weekday()
+	case calendar.ActionWeekend:
+		return s.Windows, time.Saturday
+	case calendar.ActionWindow:
+		start, end, err := ParseRange(e.Window)
+		if err != nil {
+			applog.Debugf("日历例外 %s 的时间窗口无效: %v", e.Summary, err)
+			return s.Windows, day.Weekday()
+		}
+		return []Window{{Name: e.Summary, Start: start, End: end, Mode: e.Mode}}, day.Weekday()
+	}
+	return s.Windows, day.Weekday()
+}
+
+// ActiveWindow returns the window that contains t. The exception calendar is
+// consulted first, for the day each candidate window occurrence started on.
+func (s *Settings) ActiveWindow(t time.Time) (Window, bool) {
+	w, _, ok := s.activeOccurrence(t)
+	return w, ok
+}
+
+// Night returns the date (2006-01-02) on which the window containing t started,
+// so a window that crosses midnight counts as one night
+func (s *Settings) Night(t time.Time) (string, bool) {
+	_, day, ok := s.activeOccurrence(t)
+	return day.Format("2006-01-02"), ok
+}
+
+// activeOccurrence returns the window that contains t and the day its occurrence started on
+func (s *Settings) activeOccurrence(t time.Time) (Window, time.Time, bool) {
+	for _, day := range candidateDays(t) {
+		windows, weekday := s.windowsForDay(day)
+		for _, w := range windows {
+			if w.Days.Contains(weekday) && occurrenceContains(w, day, t) {
+				return w, day, true
+			}
+		}
+	}
+	return Window{}, time.Time{}, false
+}
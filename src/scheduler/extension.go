@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// validateExtensions checks the snooze policy
+func (s *Settings) validateExtensions() error {
+	if s.Extensions.Minutes < 0 || s.Extensions.PerNight < 0 {
+		return fmt.Errorf("extensions: minutes and per_night must not be negative")
+	}
+	return nil
+}
+
+// warnWindow shows the warning for the window operation. With an extension policy the
+// user may ask for more time instead, which is recorded and applied by applyExtensions.
+func (s *Scheduler) warnWindow(now time.Time, cfg Settings, mode string, remainMinutes int) bool {
+	if s.Extensions == nil || !cfg.Extensions.Enabled() || s.AskExtension == nil || s.night == "" {
+		return s.Warn(mode, remainMinutes)
+	}
+	if !s.AskExtension(mode, remainMinutes, cfg.Extensions.Minutes) {
+		return true
+	}
+
+	r, err := s.Extensions.Request(now, s.night, mode, cfg.Extensions)
+	if err != nil {
+		log.Printf(i18n.T("log_extension_failed", err))
+	}
+	if r.Effective() {
+		log.Printf(i18n.T("log_extension_granted", r.ID, s.Extensions.Used(s.night), cfg.Extensions.PerNight))
+	} else {
+		log.Printf(i18n.T("log_extension_pending", r.ID, cfg.Extensions.PerNight))
+	}
+	return true
+}
+
+// applyExtensions moves the scheduled time by every extension of tonight that was
+// granted or approved since the last tick. The warning is shown again before the new time.
+func (s *Scheduler) applyExtensions(now time.Time) {
+	if s.Extensions == nil || s.night == "" {
+		return
+	}
+	taken, err := s.Extensions.TakeEffective(s.night)
+	if err != nil {
+		log.Printf(i18n.T("log_extension_failed", err))
+	}
+	for _, r := range taken {
+		if s.scheduledShutdownTime.Before(now) {
+			s.scheduledShutdownTime = now
+		}
+		s.scheduledShutdownTime = s.scheduledShutdownTime.Add(time.Duration(r.Minutes) * time.Minute)
+		s.warningShown = false
+		log.Printf(i18n.T("log_extension_applied", r.ID, power.OperationName(r.Mode),
+			s.scheduledShutdownTime.Format("15:04:05")))
+	}
+}
+
+// expireExtensions closes the requests of tonight that nobody answered before the operation ran
+func (s *Scheduler) expireExtensions(now time.Time) {
+	if s.Extensions == nil || s.night == "" {
+		return
+	}
+	if err := s.Extensions.Expire(s.night, now); err != nil {
+		log.Printf(i18n.T("log_extension_failed", err))
+	}
+}
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"log"
+	"strings"
+
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// runHooks runs the hooks of one phase in order, with their output going to the log.
+// It returns false if a failed hook with on_failure "abort" stopped the remaining hooks.
+func (s *Scheduler) runHooks(hooks []hook.Hook, e hook.Event) bool {
+	for _, h := range hooks {
+		if s.DryRun {
+			log.Printf(i18n.T("log_dry_run_hook", e.Phase, h.Name, strings.Join(h.Command, " ")))
+			continue
+		}
+		log.Printf(i18n.T("log_hook_started", e.Phase, h.Name))
+		err := hook.Run(h, e, func(line string) {
+			log.Printf(i18n.T("log_hook_output", h.Name, line))
+		})
+		if err == nil {
+			log.Printf(i18n.T("log_hook_finished", h.Name))
+			continue
+		}
+		log.Printf(i18n.T("log_hook_failed", h.Name, err, h.OnFailure))
+		if h.OnFailure == hook.Abort {
+			return false
+		}
+	}
+	return true
+}
+
+// tickAfterHooks runs the "after" hooks of the last operation once the machine is
+// back: on the first tick after resuming, or after the next boot from the saved state
+func (s *Scheduler) tickAfterHooks(cfg Settings) {
+	if s.afterHooks.Phase == "" || !s.resumed {
+		return
+	}
+	s.runAfterHooks(cfg)
+}
+
+// runAfterHooks runs the pending "after" hooks
+func (s *Scheduler) runAfterHooks(cfg Settings) {
+	e := s.afterHooks
+	// 先清除并保存，钩子运行期间再次关机不会重复执行
+	s.afterHooks = hook.Event{}
+	s.saveState()
+	s.runHooks(cfg.Hooks.After, e)
+}
+
+// stays reports whether the machine keeps running after mode, so the "after" hooks
+// run right away instead of waiting for a resume or the next boot
+func (s *Scheduler) stays(mode string) bool {
+	if _, dryRun := s.Backend.(power.Recorder); dryRun {
+		return true
+	}
+	return !power.Suspends(mode) && !power.Halts(mode)
+}
@@ -0,0 +1,124 @@
+//go:build linux
+// +build linux
+
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/power"
+)
+
+// modeBackend changes nothing but, unlike the dry run, is treated as a real backend
+type modeBackend struct {
+	performed []string
+}
+
+func (b *modeBackend) Perform(op power.Operation) error {
+	b.performed = append(b.performed, op.Mode)
+	return nil
+}
+
+func TestAfterHooks(t *testing.T) {
+	tests := []struct {
+		mode string
+		// 操作后立即运行、恢复后运行还是下次启动后运行
+		now, resume, boot bool
+	}{
+		{mode: "lock-session", now: true},
+		{mode: "logoff", now: true},
+		{mode: "suspend", resume: true},
+		{mode: "shutdown", boot: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			marker := filepath.Join(dir, "after")
+			cfg := nightSettings(t, tt.mode, false)
+			// 操作不在升级链中，只执行一次，不等待确认
+			cfg.Escalation = []EscalationStep{{Mode: "hibernate"}}
+			cfg.Hooks.After = []hook.Hook{{Name: "mark", Command: []string{"/bin/sh", "-c", "echo x >> " + marker}}}
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			ran := func() int {
+				data, _ := os.ReadFile(marker)
+				return len(data) / 2
+			}
+
+			start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+			s, clock, _ := newTestScheduler(cfg, start, 0, 0)
+			backend := &modeBackend{}
+			s.Backend = backend
+			s.StatePath = filepath.Join(dir, "scheduler.json")
+
+			s.Tick()
+			clock.Advance(time.Minute)
+			s.Tick()
+			if len(backend.performed) != 1 {
+				t.Fatalf("performed %v, want one operation", backend.performed)
+			}
+			want := 0
+			if tt.now {
+				want = 1
+			}
+			if ran() != want {
+				t.Fatalf("after hooks ran %d times right after the operation, want %d", ran(), want)
+			}
+
+			// 没有恢复或重新启动时，时间过去再久也不运行
+			clock.Advance(10 * time.Minute)
+			s.Tick()
+			if ran() != want {
+				t.Fatalf("after hooks ran %d times without a resume, want %d", ran(), want)
+			}
+
+			if tt.resume {
+				clock.Suspend(time.Hour)
+				s.Tick()
+			}
+			if tt.boot {
+				s = New(s.Config, nil, backend)
+				s.Clock = clock
+				s.StatePath = filepath.Join(dir, "scheduler.json")
+				if err := s.LoadState(); err != nil {
+					t.Fatal(err)
+				}
+				clock.Advance(time.Hour)
+				s.Tick()
+			}
+			if ran() != 1 {
+				t.Errorf("after hooks ran %d times, want once", ran())
+			}
+		})
+	}
+}
+
+func TestDryRunSkipsHooks(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	cfg := nightSettings(t, "shutdown", false)
+	mark := []hook.Hook{{Name: "mark", Command: []string{"/bin/sh", "-c", "echo x >> " + marker}}}
+	cfg.Hooks.Before, cfg.Hooks.After = mark, mark
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(cfg, start, 0, 0)
+	s.DryRun = true
+	runUntil(t, s, clock, backend, start.Add(time.Hour))
+	clock.Suspend(time.Hour)
+	s.Tick()
+
+	// 演练模式只记录钩子，操作照常记录
+	if n := len(backend.Records()); n != 1 {
+		t.Errorf("%d operations recorded, want 1", n)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("hooks ran in dry-run mode: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// IdleRecheck is how often the idle time is checked after a policy fired, to re-arm it
+// once the user is active again
+const IdleRecheck = time.Minute
+
+// IdlePolicy runs an operation once the user has been idle for Minutes
+// inside the policy's time range, e.g. hibernate after 30 minutes idle
+// between 20:00 and 07:00. Start equal to End means the whole day.
+type IdlePolicy struct {
+	Window
+	Minutes int `json:"minutes"`
+}
+
+// Contains reports whether the policy applies at t
+func (p IdlePolicy) Contains(t time.Time) bool {
+	if p.Start == p.End {
+		return p.Days.Contains(t.Weekday())
+	}
+	for _, day := range candidateDays(t) {
+		if p.Days.Contains(day.Weekday()) && occurrenceContains(p.Window, day, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextIdleChange returns the next time after t at which an idle policy starts or stops
+// applying. A whole-day policy changes at midnight.
+func (s *Settings) nextIdleChange(t time.Time) time.Time {
+	y, m, d := t.Date()
+	var next time.Time
+	for i := -1; i <= 7; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, t.Location())
+		for _, p := range s.Idle {
+			if !p.Days.Contains(day.Weekday()) {
+				continue
+			}
+			start, end := day, time.Date(y, m, d+i+1, 0, 0, 0, 0, t.Location())
+			if p.Start != p.End {
+				start, end = p.Occurrence(day)
+			}
+			for _, b := range []time.Time{start, end} {
+				if b.After(t) && (next.IsZero() || b.Before(next)) {
+					next = b
+				}
+			}
+		}
+	}
+	return next
+}
+
+// String returns the policy in a human readable form
+func (p IdlePolicy) String() string {
+	return fmt.Sprintf("%s, %d min", p.Window, p.Minutes)
+}
+
+// validateIdle checks the idle policies and names the ones that have none
+func (s *Settings) validateIdle() error {
+	seen := map[string]bool{}
+	for i := range s.Idle {
+		p := &s.Idle[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("idle%d", i+1)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate idle policy name %q", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Minutes <= 0 {
+			return fmt.Errorf("idle %s: minutes must be positive", p.Name)
+		}
+		if p.Mode != "" && !power.ValidMode(p.Mode) {
+			return fmt.Errorf("idle %s: invalid operation mode %q", p.Name, p.Mode)
+		}
+	}
+	return nil
+}
+
+// idleTime asks the detector for the current idle time; ok is false if there is no detector or it failed
+func (s *Scheduler) idleTime() (time.Duration, bool) {
+	if s.Idle == nil {
+		return 0, false
+	}
+	d, err := s.Idle.IdleTime()
+	if err != nil {
+		applog.Debugf("无法获取空闲时间: %v", err)
+		return 0, false
+	}
+	return d, true
+}
+
+// tickIdle runs the operation of the first active idle policy whose threshold is reached.
+// A policy fires once per idle period and is re-armed when the user is active again.
+func (s *Scheduler) tickIdle(now time.Time, cfg Settings) {
+	if len(cfg.Idle) == 0 {
+		return
+	}
+	idleFor, ok := s.idleTime()
+	if !ok {
+		return
+	}
+	// 策略开始或结束时重新检查
+	s.plan(now, cfg.nextIdleChange(now))
+
+	// 用户重新活动后允许再次触发：空闲时间很短，或比上次检查以来的时间短
+	active := idleFor < PollInterval*2 || (!s.idleChecked.IsZero() && idleFor < now.Sub(s.idleChecked))
+	s.idleChecked = now
+	if active {
+		if s.idleFired {
+			applog.Debugf("检测到用户活动，空闲策略重新生效")
+		}
+		s.idleFired = false
+		s.idleDue = time.Time{}
+	}
+	if s.idleFired {
+		// 只能通过再次查询空闲时间发现用户重新活动
+		s.plan(now, now.Add(IdleRecheck))
+		return
+	}
+	if now.Before(s.idleRecheck) {
+		s.plan(now, s.idleRecheck)
+		return
+	}
+
+	for _, p := range cfg.Idle {
+		if !p.Contains(now) {
+			continue
+		}
+		threshold := time.Duration(p.Minutes) * time.Minute
+		if idleFor < threshold {
+			// 用户一直不活动时最早的触发时间
+			s.plan(now, now.Add(threshold-idleFor))
+			continue
+		}
+		mode := p.ModeOr(cfg.Mode)
+
+		// 白名单进程运行或系统繁忙时推迟
+		if s.idleDue.IsZero() {
+			s.idleDue = now
+		}
+		if s.deferForProcesses(now, cfg, mode, "idle "+p.Name, s.idleDue) ||
+			s.postpone(now, cfg, mode, "idle "+p.Name, s.idleDue) {
+			s.idleRecheck = now.Add(PostponeRecheck)
+			s.plan(now, s.idleRecheck)
+			return
+		}
+		s.idleDue = time.Time{}
+
+		log.Printf(i18n.T("log_idle_fired", p.Name, int(idleFor.Minutes()), power.OperationName(mode)))
+		s.idleFired = true
+		s.PerformOperation(mode, "idle "+p.Name, now)
+		s.plan(now, now.Add(IdleRecheck))
+		return
+	}
+}
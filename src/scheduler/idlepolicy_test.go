@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/idle"
+)
+
+// idleSettings returns settings without windows and a single idle policy
+func idleSettings(t *testing.T, policy IdlePolicy) Settings {
+	t.Helper()
+	s := Settings{Mode: "hibernate", TimeZone: "UTC", Idle: []IdlePolicy{policy}}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	return s
+}
+
+func TestIdlePolicyPlansRange(t *testing.T) {
+	policy := IdlePolicy{Window: Window{Name: "evening", Start: TimeOfDay{Hour: 20}, End: TimeOfDay{Hour: 21}}, Minutes: 30}
+	start := time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(idleSettings(t, policy), start)
+	detector := &idle.Fake{}
+	detector.Set(2 * time.Hour)
+	s.Idle = detector
+
+	// 策略开始时醒来，用户已经空闲足够久，立即执行
+	s.Tick()
+	if at := s.wakeAt(); !at.Equal(start.Add(time.Hour)) {
+		t.Fatalf("wakeAt() = %v before the policy, want 20:00", at)
+	}
+	clock.Advance(time.Hour)
+	s.Tick()
+	if n := len(backend.Records()); n != 1 {
+		t.Fatalf("%d operations at 20:00, want 1", n)
+	}
+
+	// 触发后定期检查用户是否重新活动
+	if at := s.wakeAt(); !at.Equal(clock.Now().Add(IdleRecheck)) {
+		t.Errorf("wakeAt() = %v after firing, want %v", at, clock.Now().Add(IdleRecheck))
+	}
+}
+
+func TestIdlePolicyFiresAndRearms(t *testing.T) {
+	policy := IdlePolicy{Window: Window{Name: "night", Start: TimeOfDay{Hour: 20}, End: TimeOfDay{Hour: 7}}, Minutes: 30}
+	start := time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(idleSettings(t, policy), start)
+	detector := &idle.Fake{}
+	s.Idle = detector
+	operations := func() int { return len(backend.Records()) }
+
+	// 空闲 10 分钟，再过 20 分钟达到阈值
+	detector.Set(10 * time.Minute)
+	s.Tick()
+	if at := s.wakeAt(); !at.Equal(start.Add(20 * time.Minute)) {
+		t.Fatalf("wakeAt() = %v, want 21:20 when the threshold is reached", at)
+	}
+	clock.Advance(20 * time.Minute)
+	detector.Set(30 * time.Minute)
+	s.Tick()
+	if r := backend.Records(); len(r) != 1 || r[0].Reason != "idle night" || r[0].Mode != "hibernate" {
+		t.Fatalf("Records() = %+v, want one idle hibernation", r)
+	}
+
+	// 用户一直不活动时不再重复执行
+	clock.Advance(IdleRecheck)
+	detector.Set(31 * time.Minute)
+	s.Tick()
+	if n := operations(); n != 1 {
+		t.Fatalf("%d operations while still idle, want 1", n)
+	}
+
+	// 用户重新活动后再次生效，从上次输入开始计算阈值
+	clock.Advance(IdleRecheck)
+	detector.Set(20 * time.Second)
+	s.Tick()
+	if n := operations(); n != 1 {
+		t.Fatalf("%d operations right after activity, want 1", n)
+	}
+	if at, want := s.wakeAt(), clock.Now().Add(30*time.Minute-20*time.Second); !at.Equal(want) {
+		t.Errorf("wakeAt() = %v after activity, want %v", at, want)
+	}
+	clock.Advance(30*time.Minute - 20*time.Second)
+	detector.Set(30 * time.Minute)
+	s.Tick()
+	if n := operations(); n != 2 {
+		t.Errorf("%d operations after idling again, want 2", n)
+	}
+}
+
+func TestIdlePolicyRange(t *testing.T) {
+	night := Window{Name: "night", Start: TimeOfDay{Hour: 20}, End: TimeOfDay{Hour: 7}}
+	friday := night
+	friday.Days = Weekdays{time.Friday}
+	weekend := Window{Name: "weekend", Days: Weekdays{time.Saturday, time.Sunday}}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 2026-10-19 是星期一，10-23 是星期五
+	tests := []struct {
+		name   string
+		window Window
+		now    time.Time
+		fires  bool
+	}{
+		{"before the start", night, at(19, 19, 59), false},
+		{"at the start", night, at(19, 20, 0), true},
+		{"after midnight", night, at(20, 3, 0), true},
+		{"at the end", night, at(20, 7, 0), false},
+		{"during the day", night, at(20, 12, 0), false},
+		{"started on an included day", friday, at(24, 3, 0), true},
+		{"started on an excluded day", friday, at(25, 3, 0), false},
+		{"whole included day", weekend, at(24, 12, 0), true},
+		{"whole excluded day", weekend, at(19, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, backend := newTestScheduler(idleSettings(t, IdlePolicy{Window: tt.window, Minutes: 30}), tt.now)
+			detector := &idle.Fake{}
+			detector.Set(2 * time.Hour)
+			s.Idle = detector
+			s.Tick()
+			if fired := len(backend.Records()) == 1; fired != tt.fires {
+				t.Errorf("fired = %v at %v, want %v", fired, tt.now, tt.fires)
+			}
+		})
+	}
+}
+
+func TestIdlePolicyDetectorFails(t *testing.T) {
+	policy := IdlePolicy{Window: Window{Name: "day"}, Minutes: 30}
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s, _, backend := newTestScheduler(idleSettings(t, policy), start)
+	detector := &idle.Fake{}
+	detector.Set(2 * time.Hour)
+	detector.Fail(errors.New("no display"))
+	s.Idle = detector
+
+	// 无法获取空闲时间时不执行操作
+	s.Tick()
+	if n := len(backend.Records()); n != 0 {
+		t.Errorf("%d operations without an idle time, want 0", n)
+	}
+}
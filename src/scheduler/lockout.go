@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// applyLockout schedules the operation after the fixed lockout grace instead of a random
+// delay if the window was already enforced tonight. Only a boot or resume counts as an
+// attempt; other checks, e.g. after a failed operation or a lock that kept the machine
+// running, are not attempts to use it.
+func (s *Scheduler) applyLockout(now time.Time, cfg Settings, mode string) {
+	if s.Lockout == nil || !cfg.Lockout.Enabled || s.night == "" {
+		return
+	}
+	r, ok := s.Lockout.Enforced(s.activeWindow, s.night)
+	if ok && s.resumed {
+		var err error
+		if r, ok, err = s.Lockout.Attempt(s.activeWindow, s.night, now); err != nil {
+			log.Printf(i18n.T("log_lockout_failed", err))
+		}
+	}
+	if !ok {
+		return
+	}
+
+	s.scheduledShutdownTime = now.Add(cfg.Lockout.Grace())
+	s.shutdownScheduled = true
+	if s.resumed {
+		log.Printf(i18n.T("log_lockout_attempt", s.activeWindow, r.Enforced.In(now.Location()).Format("15:04:05"),
+			len(r.Attempts), power.OperationName(mode), s.scheduledShutdownTime.Format("15:04:05")))
+	} else {
+		applog.Debugf("窗口 %s 已锁定，不再随机延迟", s.activeWindow)
+	}
+}
+
+// recordEnforcement locks the current window occurrence before its operation runs
+func (s *Scheduler) recordEnforcement(now time.Time, cfg Settings) {
+	if s.Lockout == nil || !cfg.Lockout.Enabled || s.night == "" {
+		return
+	}
+	if err := s.Lockout.Enforce(s.activeWindow, s.night, now); err != nil {
+		log.Printf(i18n.T("log_lockout_failed", err))
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/lockout"
+)
+
+func TestLockoutCountsPowerOnOnly(t *testing.T) {
+	cfg := nightSettings(t, "shutdown", false)
+	cfg.Lockout = lockout.Config{Enabled: true, GraceSeconds: 120}
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(cfg, start, 0, 0)
+	tracker, err := lockout.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Lockout = tracker
+
+	attempts := func() int {
+		t.Helper()
+		records := tracker.List()
+		if len(records) != 1 {
+			t.Fatalf("List() = %+v, want one enforced window", records)
+		}
+		return len(records[0].Attempts)
+	}
+
+	// 服务启动时窗口还没有执行过操作，使用随机延迟
+	s.Tick()
+	clock.Advance(time.Minute)
+	s.Tick()
+	if n := len(backend.Records()); n != 1 {
+		t.Fatalf("%d operations at 22:01, want 1", n)
+	}
+
+	// 操作没有让机器停下，后续检查只给宽限时间，不算作开机尝试
+	clock.Advance(30 * time.Minute)
+	s.Tick()
+	if at, ok := s.ScheduledTime(); !ok || !at.Equal(clock.Now().Add(2*time.Minute)) {
+		t.Errorf("ScheduledTime() = %v, %v, want the grace after %v", at, ok, clock.Now())
+	}
+	if n := attempts(); n != 0 {
+		t.Errorf("%d attempts counted without a boot or resume", n)
+	}
+	clock.Advance(2 * time.Minute)
+	s.Tick()
+	if n := len(backend.Records()); n != 2 {
+		t.Fatalf("%d operations after the grace, want 2", n)
+	}
+	if n := attempts(); n != 0 {
+		t.Errorf("%d attempts counted without a boot or resume", n)
+	}
+
+	// 从睡眠中恢复算一次尝试
+	clock.Suspend(time.Hour)
+	s.Tick()
+	if n := attempts(); n != 1 {
+		t.Errorf("%d attempts after a resume, want 1", n)
+	}
+	if at, ok := s.ScheduledTime(); !ok || !at.Equal(clock.Now().Add(2*time.Minute)) {
+		t.Errorf("ScheduledTime() = %v, %v, want the grace after %v", at, ok, clock.Now())
+	}
+
+	// 服务重新启动算一次开机
+	restarted := New(s.Config, nil, backend)
+	restarted.Clock = clock
+	restarted.Lockout = tracker
+	clock.Advance(time.Hour)
+	restarted.Tick()
+	if n := attempts(); n != 2 {
+		t.Errorf("%d attempts after a boot, want 2", n)
+	}
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/process"
+)
+
+// waitFor polls cond until it holds; the loop runs in another goroutine
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestRunWakesOnResume(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s, clock, _ := newTestScheduler(nightSettings(t, "shutdown", false), start, 4, 0)
+	done := make(chan struct{})
+	go func() {
+		s.Run()
+		close(done)
+	}()
+
+	// 只等待下一个窗口开始，不再每分钟检查
+	waitFor(t, "the loop to wait", func() bool { return clock.Waiters() == 1 })
+	clock.Advance(9 * time.Hour)
+	if clock.Waiters() != 1 {
+		t.Fatalf("the loop woke up before the window")
+	}
+
+	// 挂起期间进入窗口，恢复后立即检查
+	clock.Suspend(2 * time.Hour)
+	waitFor(t, "the loop to plan the operation", func() bool { return clock.Waiters() == 1 })
+	if at, ok := s.ScheduledTime(); !ok || !at.Equal(start.Add(11*time.Hour+5*time.Minute)) {
+		t.Errorf("ScheduledTime() = %v, %v, want 23:05 after resuming at 23:00", at, ok)
+	}
+
+	s.Stop()
+	<-done
+}
+
+// fakeTable is a process table that records the processes killed
+type fakeTable struct {
+	mu     sync.Mutex
+	procs  []process.Process
+	killed []int
+}
+
+func (f *fakeTable) List() ([]process.Process, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]process.Process(nil), f.procs...), nil
+}
+
+func (f *fakeTable) Kill(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.killed = append(f.killed, pid)
+	return nil
+}
+
+func (f *fakeTable) kills() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.killed)
+}
+
+func TestBlocklistWatcher(t *testing.T) {
+	cfg := nightSettings(t, "shutdown", false)
+	cfg.Processes.Block = []string{"game"}
+	start := time.Date(2026, 10, 19, 21, 59, 0, 0, time.UTC)
+	s, clock, _ := newTestScheduler(cfg, start, 9, 0)
+	table := &fakeTable{procs: []process.Process{{PID: 42, Name: "game"}, {PID: 7, Name: "editor"}}}
+	s.Processes = table
+	done := make(chan struct{})
+	go func() {
+		s.Run()
+		close(done)
+	}()
+
+	// 窗口外不查找进程
+	waitFor(t, "the loop to wait", func() bool { return clock.Waiters() == 1 })
+	if n := table.kills(); n != 0 {
+		t.Fatalf("%d processes killed outside the window", n)
+	}
+
+	// 窗口内每 PollInterval 查找一次，计划中的操作之外主循环不被唤醒
+	clock.Advance(time.Minute)
+	waitFor(t, "the first kill", func() bool { return table.kills() == 1 && clock.Waiters() == 2 })
+	clock.Advance(PollInterval)
+	waitFor(t, "the second kill", func() bool { return table.kills() == 2 && clock.Waiters() == 2 })
+	if table.killed[0] != 42 || table.killed[1] != 42 {
+		t.Errorf("killed %v, want only PID 42", table.killed)
+	}
+
+	s.Stop()
+	<-done
+}
+
+func TestRunRepeatsOperationThatKeepsRunning(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(nightSettings(t, "logoff", false), start, 2, 30, 4, 0)
+	done := make(chan struct{})
+	go func() {
+		s.Run()
+		close(done)
+	}()
+	operations := func(n int) func() bool {
+		return func() bool { return len(backend.Records()) == n && clock.Waiters() == 1 }
+	}
+
+	waitFor(t, "the loop to wait", operations(0))
+	clock.Advance(3*time.Minute + 30*time.Second)
+	waitFor(t, "the first logoff", operations(1))
+
+	// 注销后电脑仍在窗口内，很快再次检查并重新计划随机延迟
+	clock.Advance(OperationRecheck)
+	waitFor(t, "the loop to plan again", func() bool {
+		at, ok := s.ScheduledTime()
+		return ok && clock.Waiters() == 1 && at.Equal(clock.Now().Add(5*time.Minute))
+	})
+	clock.Advance(5 * time.Minute)
+	waitFor(t, "the second logoff", operations(2))
+
+	s.Stop()
+	<-done
+}
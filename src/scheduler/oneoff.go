@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// tickQueue runs due one-off operations from the queue through the usual warning path.
+// Entries missed by more than CronMissedGrace (e.g. the machine was off) are dropped.
+func (s *Scheduler) tickQueue(now time.Time, cfg Settings) {
+	if s.Queue == nil {
+		return
+	}
+
+	for _, e := range s.Queue.List() {
+		if now.Sub(e.At) > CronMissedGrace {
+			log.Printf(i18n.T("log_queue_missed", e.ID, power.OperationName(e.Mode), e.At.Format("2006-01-02 15:04")))
+			s.dequeue(e.ID)
+			continue
+		}
+
+		// 到达警告时间
+		warningTime := e.At.Add(-time.Duration(cfg.WarningMinutes) * time.Minute)
+		if cfg.ShowWarning && !e.Warned && !now.Before(warningTime) && now.Before(e.At) {
+			remainMinutes := int(e.At.Sub(now).Minutes())
+			if remainMinutes < 1 {
+				remainMinutes = 1
+			}
+			if err := s.Queue.MarkWarned(e.ID); err != nil {
+				log.Printf(i18n.T("log_queue_save_failed", err))
+			}
+			if !s.Warn(e.Mode, remainMinutes) {
+				log.Printf(i18n.T("shutdown_cancelled", power.OperationName(e.Mode)))
+				s.dequeue(e.ID)
+				continue
+			}
+		}
+
+		// 到达计划时间，先从队列中删除，关机后不会再次执行
+		if !now.Before(e.At) {
+			log.Printf(i18n.T("log_queue_fired", e.ID, power.OperationName(e.Mode)))
+			s.dequeue(e.ID)
+			s.PerformOperation(e.Mode, fmt.Sprintf("queue #%d", e.ID), e.At)
+			continue
+		}
+
+		if cfg.ShowWarning && !e.Warned {
+			s.plan(now, warningTime)
+		}
+		s.plan(now, e.At)
+	}
+}
+
+// dequeue removes an entry and logs a failure to save the queue
+func (s *Scheduler) dequeue(id int) {
+	if _, err := s.Queue.Cancel(id); err != nil {
+		log.Printf(i18n.T("log_queue_save_failed", err))
+	}
+}
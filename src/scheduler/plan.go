@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"time"
+
+	"codans.com/autoshut/src/applog"
+)
+
+// QuotaPoll is how often active time is counted while a quota is configured; it has
+// to stay below quota.MaxTickGap for the time in between to count as use
+const QuotaPoll = 30 * time.Second
+
+// plan records t as a candidate for the next evaluation. Times not after now are ignored.
+func (s *Scheduler) plan(now, t time.Time) {
+	if !t.After(now) {
+		return
+	}
+	if s.next.IsZero() || t.Before(s.next) {
+		s.next = t
+	}
+}
+
+// wakeAt returns the wall-clock time the loop waits for after the last evaluation, or
+// the zero time if nothing is planned. It is taken from the clock rather than from the
+// evaluated time, which may be corrected for a clock that was set back. The loop is
+// also woken when the wall clock is set or the machine resumes, so there is no upper
+// limit on the wait.
+func (s *Scheduler) wakeAt() time.Time {
+	if s.next.IsZero() {
+		applog.Debugf("没有计划的检查，等待配置更改或系统恢复")
+		return time.Time{}
+	}
+	at := s.wall.Add(s.next.Sub(s.evaluated))
+	applog.Debugf("%s 再次检查", at.Format("2006-01-02 15:04:05"))
+	return at
+}
+
+// nextWindowChange returns the next start or end of a window occurrence after t
+func (s *Settings) nextWindowChange(t time.Time) time.Time {
+	y, m, d := t.Date()
+	var next time.Time
+	for i := -1; i <= 7; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, t.Location())
+		windows, weekday := s.windowsForDay(day)
+		for _, w := range windows {
+			if !w.Days.Contains(weekday) {
+				continue
+			}
+			start, end := w.Occurrence(day)
+			for _, b := range []time.Time{start, end} {
+				if b.After(t) && (next.IsZero() || b.Before(next)) {
+					next = b
+				}
+			}
+		}
+	}
+	return next
+}
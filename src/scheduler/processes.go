@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/process"
+)
+
+// validateProcesses checks the process lists
+func (s *Settings) validateProcesses() error {
+	if len(s.Processes.Allow) > 0 && s.Processes.MaxDeferMinutes <= 0 {
+		return fmt.Errorf("processes: max_defer_minutes must be positive")
+	}
+	for _, name := range append(append([]string(nil), s.Processes.Allow...), s.Processes.Block...) {
+		if process.Normalize(name) == "" {
+			return fmt.Errorf("processes: empty process name")
+		}
+	}
+	return nil
+}
+
+// deferForProcesses reports whether running allowlisted processes defer an
+// operation that has been due since due. After max_defer_minutes it runs anyway.
+func (s *Scheduler) deferForProcesses(now time.Time, cfg Settings, mode, reason string, due time.Time) bool {
+	if s.Processes == nil || len(cfg.Processes.Allow) == 0 {
+		return false
+	}
+	procs, err := s.Processes.List()
+	if err != nil {
+		applog.Debugf("无法获取进程列表: %v", err)
+		return false
+	}
+	running := process.Match(procs, cfg.Processes.Allow)
+	if len(running) == 0 {
+		return false
+	}
+
+	names := process.Join(running)
+	if now.Sub(due) >= time.Duration(cfg.Processes.MaxDeferMinutes)*time.Minute {
+		log.Printf(i18n.T("log_process_defer_limit", power.OperationName(mode), names, cfg.Processes.MaxDeferMinutes))
+		return false
+	}
+	log.Printf(i18n.T("log_process_deferred", power.OperationName(mode), names, reason))
+	return true
+}
+
+// setBlocking tells watchBlocklist whether blocklisted processes have to be terminated
+func (s *Scheduler) setBlocking(blocking bool) {
+	if blocking == s.blocking {
+		return
+	}
+	s.blocking = blocking
+	// 只保留最新的状态
+	select {
+	case <-s.blocklist:
+	default:
+	}
+	s.blocklist <- blocking
+}
+
+// watchBlocklist terminates blocklisted processes every PollInterval while setBlocking
+// says so. It runs beside the loop until done is closed, so that the loop itself only
+// wakes up for planned events.
+func (s *Scheduler) watchBlocklist(done <-chan struct{}) {
+	blocking := false
+	for {
+		var poll <-chan time.Time
+		if blocking {
+			s.enforceBlocklist(s.Config.Get())
+			poll = s.Clock.After(PollInterval)
+		}
+		select {
+		case blocking = <-s.blocklist:
+			if !blocking {
+				s.killed = nil
+			}
+		case <-poll:
+		case <-done:
+			return
+		}
+	}
+}
+
+// enforceBlocklist terminates running blocklisted processes
+func (s *Scheduler) enforceBlocklist(cfg Settings) {
+	if s.Processes == nil || len(cfg.Processes.Block) == 0 {
+		return
+	}
+	procs, err := s.Processes.List()
+	if err != nil {
+		applog.Debugf("无法获取进程列表: %v", err)
+		return
+	}
+	if s.killed == nil {
+		s.killed = map[int]bool{}
+	}
+	for _, p := range process.Match(procs, cfg.Processes.Block) {
+		if s.DryRun {
+			// 演练模式只记录一次，不结束进程
+			if !s.killed[p.PID] {
+				s.killed[p.PID] = true
+				log.Printf(i18n.T("log_dry_run_kill", p))
+			}
+			continue
+		}
+		err := s.Processes.Kill(p.PID)
+		// 同一进程只记录一次，避免忽略 SIGTERM 的进程刷屏
+		if s.killed[p.PID] {
+			applog.Debugf("再次结束进程 %s: %v", p, err)
+			continue
+		}
+		s.killed[p.PID] = true
+		if err != nil {
+			log.Printf(i18n.T("log_process_kill_failed", p, err))
+		} else {
+			log.Printf(i18n.T("log_process_killed", p))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/process"
+)
+
+func TestEnforceBlocklist(t *testing.T) {
+	cfg := nightSettings(t, "shutdown", false)
+	cfg.Processes.Block = []string{"Game.exe"}
+	for _, dryRun := range []bool{false, true} {
+		s, _, _ := newTestScheduler(cfg, time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC))
+		procs := &process.Fake{}
+		procs.Start(10, "game.exe")
+		procs.Start(11, "editor")
+		s.Processes = procs
+		s.DryRun = dryRun
+
+		s.enforceBlocklist(cfg)
+		var want []int
+		var running []process.Process
+		if dryRun {
+			// 演练模式不结束进程
+			running = []process.Process{{PID: 10, Name: "game.exe"}, {PID: 11, Name: "editor"}}
+		} else {
+			want = []int{10}
+			running = []process.Process{{PID: 11, Name: "editor"}}
+		}
+		if got := procs.Killed(); !reflect.DeepEqual(got, want) {
+			t.Errorf("dry run %v: Killed() = %v, want %v", dryRun, got, want)
+		}
+		if got, _ := procs.List(); !reflect.DeepEqual(got, running) {
+			t.Errorf("dry run %v: List() = %v, want %v", dryRun, got, running)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// quotaActive reports whether the user counts as active for the screen-time quota
+func (s *Scheduler) quotaActive(cfg Settings) bool {
+	if cfg.Quota.IdleMinutes <= 0 {
+		return true
+	}
+	idleFor, ok := s.idleTime()
+	if !ok {
+		return true
+	}
+	return idleFor < time.Duration(cfg.Quota.IdleMinutes)*time.Minute
+}
+
+// tickQuota counts active time and runs the quota operation, with the usual warning, once it is used up
+func (s *Scheduler) tickQuota(now time.Time, cfg Settings) {
+	if s.Quota == nil || !cfg.Quota.Enabled() {
+		return
+	}
+
+	if err := s.Quota.Tick(now, s.quotaActive(cfg)); err != nil {
+		log.Printf(i18n.T("log_quota_save_failed", err))
+	}
+	// 使用时间只在两次检查间隔不超过 quota.MaxTickGap 时计入
+	s.plan(now, now.Add(QuotaPoll))
+
+	mode := cfg.Quota.Mode
+	if mode == "" {
+		mode = cfg.Mode
+	}
+	remaining := s.Quota.Remaining(now)
+	warnAhead := time.Duration(cfg.WarningMinutes) * time.Minute
+	if !cfg.ShowWarning {
+		warnAhead = 0
+	}
+
+	// 剩余时间充足（例如刚追加了时间），取消已计划的操作
+	if remaining > warnAhead && remaining > 0 {
+		if !s.quotaDeadline.IsZero() {
+			applog.Debugf("屏幕时间已追加，取消配额操作")
+		}
+		s.quotaDeadline = time.Time{}
+		s.quotaWarned = false
+		return
+	}
+
+	if s.quotaDeadline.IsZero() {
+		// 配额已用完时（例如重新开机）至少给一分钟
+		if remaining < time.Minute {
+			remaining = time.Minute
+		}
+		s.quotaDeadline = now.Add(remaining)
+		log.Printf(i18n.T("log_quota_exhausting", s.quotaDeadline.Format("15:04:05"), power.OperationName(mode)))
+	}
+
+	if cfg.ShowWarning && !s.quotaWarned && now.Before(s.quotaDeadline) {
+		remainMinutes := int(s.quotaDeadline.Sub(now).Minutes())
+		if remainMinutes < 1 {
+			remainMinutes = 1
+		}
+		s.quotaWarned = true
+		if !s.Warn(mode, remainMinutes) {
+			log.Printf(i18n.T("shutdown_cancelled", power.OperationName(mode)))
+			s.quotaDeadline = now.Add(warnAhead)
+			s.quotaWarned = false
+			return
+		}
+	}
+
+	if !now.Before(s.quotaDeadline) {
+		log.Printf(i18n.T("log_quota_exhausted", power.OperationName(mode)))
+		s.PerformOperation(mode, "quota", s.quotaDeadline)
+		s.quotaDeadline = time.Time{}
+		s.quotaWarned = false
+		return
+	}
+	s.plan(now, s.quotaDeadline)
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/quota"
+)
+
+// quotaScheduler returns a scheduler without windows, a daily quota of two minutes and
+// a one-minute warning that records when it is shown
+func quotaScheduler(t *testing.T, start time.Time) (*Scheduler, *FakeClock, *power.DryRun, *[]time.Time) {
+	t.Helper()
+	cfg := Settings{Mode: "hibernate", ShowWarning: true, WarningMinutes: 1, TimeZone: "UTC",
+		Quota: quota.Config{DailyMinutes: 2}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	s, clock, backend := newTestScheduler(cfg, start)
+	tracker, err := quota.Open("", 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Quota = tracker
+	warnings := &[]time.Time{}
+	s.Warn = func(mode string, minutes int) bool {
+		*warnings = append(*warnings, clock.Now())
+		return true
+	}
+	return s, clock, backend, warnings
+}
+
+func TestQuotaExhausted(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s, clock, backend, warnings := quotaScheduler(t, start)
+
+	// 每 QuotaPoll 计时一次，剩余一分钟时警告，用完时执行
+	runUntil(t, s, clock, backend, start.Add(time.Hour))
+	records := backend.Records()
+	if len(records) != 1 || records[0].Reason != "quota" || records[0].Mode != "hibernate" {
+		t.Fatalf("Records() = %+v, want one quota hibernation", records)
+	}
+	if !records[0].Time.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("quota operation at %v, want 12:02", records[0].Time)
+	}
+	// 第一次是剩余一分钟时的警告，第二次是执行前的确认
+	if w := *warnings; len(w) != 2 || !w[0].Equal(start.Add(time.Minute)) || !w[1].Equal(start.Add(2*time.Minute)) {
+		t.Errorf("warnings at %v, want 12:01 and 12:02", w)
+	}
+
+	// 当天再次开机只多给一分钟
+	clock.Suspend(time.Hour)
+	s.Tick()
+	if at := s.wakeAt(); !at.Equal(clock.Now().Add(QuotaPoll)) {
+		t.Errorf("wakeAt() = %v after booting with the quota used up, want the next count", at)
+	}
+	if !s.quotaDeadline.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("quota deadline %v after booting again, want one minute after %v", s.quotaDeadline, clock.Now())
+	}
+}
+
+func TestQuotaGrantCancels(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s, clock, backend, warnings := quotaScheduler(t, start)
+
+	runUntil(t, s, clock, backend, start.Add(time.Minute+time.Second))
+	if len(*warnings) != 1 {
+		t.Fatalf("%d warnings at 12:01, want 1", len(*warnings))
+	}
+
+	// 追加时间后取消计划的操作，剩余时间再次不足时重新警告
+	s.Quota.Grant(clock.Now(), 5*time.Minute)
+	runUntil(t, s, clock, backend, start.Add(6*time.Minute+time.Second))
+	if n := len(backend.Records()); n != 0 {
+		t.Fatalf("%d operations after granting time, want 0", n)
+	}
+	runUntil(t, s, clock, backend, start.Add(time.Hour))
+	if records := backend.Records(); len(records) != 1 || !records[0].Time.Equal(start.Add(7*time.Minute)) {
+		t.Errorf("Records() = %+v, want one operation at 12:07", records)
+	}
+	if w := *warnings; len(w) != 3 || !w[1].Equal(start.Add(6*time.Minute)) {
+		t.Errorf("warnings at %v, want 12:01, 12:06 and the confirmation at 12:07", w)
+	}
+}
@@ -0,0 +1,549 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/activity"
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/extension"
+	"codans.com/autoshut/src/history"
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/idle"
+	"codans.com/autoshut/src/lockout"
+	"codans.com/autoshut/src/power"
+	"codans.com/autoshut/src/process"
+	"codans.com/autoshut/src/queue"
+	"codans.com/autoshut/src/quota"
+	"codans.com/autoshut/src/tamper"
+	"codans.com/autoshut/src/wake"
+)
+
+// PollInterval is how often blocklisted processes are looked for while a window is active.
+// The loop itself only wakes up for planned events; the processes are watched beside it.
+const PollInterval = 10 * time.Second
+
+// OperationRecheck is how soon the window is checked again after an operation that left
+// the machine running, e.g. logoff, lock-session or a refused operation. That check rolls
+// a new delay, so the window is enforced again.
+const OperationRecheck = 10 * time.Second
+
+// Scheduler runs the automatic operation inside the configured time range
+type Scheduler struct {
+	Config *Config
+
+	// Warn shows the warning dialog and returns true if the user confirms to continue
+	Warn func(mode string, minutes int) bool
+	// AskExtension shows the warning with the option to ask for another extend minutes and
+	// returns true if the user asks for them; nil shows the plain warning
+	AskExtension func(mode string, minutes, extend int) bool
+	// Backend carries out the operation
+	Backend power.Backend
+	// DryRun only logs the blocklisted processes and hooks instead of terminating or running them
+	DryRun bool
+	// Clock and Rand drive the loop; tests replace them with FakeClock and SequenceRand
+	Clock Clock
+	Rand  Rand
+	// Quota counts daily screen time, nil if no quota is configured
+	Quota *quota.Tracker
+	// Idle reports how long the user has been idle; nil disables idle policies
+	// and counts all powered-on time as use for the quota
+	Idle idle.Detector
+	// Busy postpones due operations while the machine is busy, nil never postpones
+	Busy *activity.Guard
+	// Processes finds allowlisted and blocklisted processes, nil disables both lists
+	Processes process.Table
+	// Queue holds one-off operations scheduled remotely, nil disables them
+	Queue *queue.Queue
+	// Extensions records snooze requests, nil disables them
+	Extensions *extension.Ledger
+	// Lockout records enforced windows and later attempts to use the machine, nil disables it
+	Lockout *lockout.Tracker
+	// Tamper detects changes of the system clock, nil trusts the wall clock
+	Tamper *tamper.Detector
+	// Wake arms the wake-up for the configured wake times, nil disables them
+	Wake wake.Timer
+	// History records every operation and the escalation steps it reached, nil keeps no record
+	History *history.Log
+	// StatePath is the file the window state is saved to, empty keeps it in memory only
+	StatePath string
+
+	// 记录上次检测到进入时间范围的时间
+	lastEnteredPeriod time.Time
+	// 记录是否已经计划了一次随机关机
+	shutdownScheduled bool
+	// 记录计划的关机时间
+	scheduledShutdownTime time.Time
+	// 跟踪是否已显示过警告对话框
+	warningShown bool
+	// 上次保存到状态文件的内容
+	saved state
+	// 当前所在的时间窗口名称
+	activeWindow string
+	// 当前窗口开始的日期，用于统计每晚的延长次数
+	night string
+	// 每个定时任务的下次触发状态
+	cron map[string]*cronState
+	// 屏幕时间用完后计划执行操作的时间
+	quotaDeadline time.Time
+	quotaWarned   bool
+	// 空闲策略已触发，等待用户重新活动
+	idleFired bool
+	// 因系统繁忙或白名单进程推迟的操作：原定执行时间和操作模式
+	postponedDue  time.Time
+	postponedMode string
+	// 空闲策略因系统繁忙推迟：原定执行时间和下次检查时间
+	idleDue     time.Time
+	idleRecheck time.Time
+	idleChecked time.Time
+	// 本次窗口内已经结束过的黑名单进程，只由 watchBlocklist 使用
+	killed map[int]bool
+	// 是否需要结束黑名单进程，变化时通知 watchBlocklist
+	blocking  bool
+	blocklist chan bool
+	// 定时唤醒已设置的时间
+	wakeArmed time.Time
+	// 上次操作之后等待运行的 after 钩子
+	afterHooks hook.Event
+	// 等待电源状态改变的升级步骤
+	pending *escalation
+	// 其他 goroutine 请求立即执行的操作，在循环中执行
+	requests chan request
+	// 是否已经检查过一次，以及当时系统挂起过的总时间，用于识别开机和恢复
+	ticked    bool
+	suspended time.Duration
+	// 本次检查是否在开机或恢复之后
+	resumed bool
+	// 下次需要检查的时间，由各项检查在 Tick 中计算
+	next      time.Time
+	evaluated time.Time // 本次检查的时间
+	wall      time.Time // 本次检查开始时时钟的墙上时间，用于换算下次检查的时间
+	// 模拟运行，不写计划和执行的日志
+	simulated bool
+	// 停止循环
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a Scheduler that uses the given config, warning dialog and power backend
+func New(cfg *Config, warn func(mode string, minutes int) bool, backend power.Backend) *Scheduler {
+	return &Scheduler{
+		Config:    cfg,
+		Warn:      warn,
+		Backend:   backend,
+		Clock:     RealClock{},
+		Rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:      make(chan struct{}),
+		blocklist: make(chan bool, 1),
+		requests:  make(chan request),
+	}
+}
+
+// request is an operation asked for by Perform
+type request struct {
+	mode, reason string
+	result       chan error
+}
+
+// Perform runs mode right away, without the warning, the way the loop runs its own
+// operations: with the hooks, the escalation chain and the history. It is safe to call
+// from other goroutines and waits for Run to pick the request up. It returns once the
+// operation has started, with an error if a hook cancelled it or every step was refused.
+func (s *Scheduler) Perform(mode, reason string) error {
+	r := request{mode: mode, reason: reason, result: make(chan error, 1)}
+	select {
+	case s.requests <- r:
+		return <-r.result
+	case <-s.stop:
+		return errors.New("scheduler stopped")
+	}
+}
+
+// Run is the scheduler loop (doIt). It sleeps until the next relevant instant computed
+// by Tick, until the configuration changes, until the wall clock is set or the machine
+// resumes, or until Perform asks for an operation. Once the user cancels a warning it
+// stops scheduling operations but still verifies the pending one and runs those asked
+// for by Perform. It returns when Stop is called.
+func (s *Scheduler) Run() {
+	// 调试模式下记录初始化信息
+	applog.Debugf("doIt函数已启动，开始监控时间范围")
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.watchBlocklist(done)
+
+	scheduling := true
+	for {
+		if scheduling {
+			if scheduling = s.Tick(); !scheduling {
+				applog.Debugf("用户取消了操作，停止自动计划")
+				s.setBlocking(false)
+			}
+		} else {
+			s.tickPending()
+		}
+
+		alarm, release := s.Clock.Alarm(s.wakeAt())
+		select {
+		case <-alarm:
+		case <-s.Config.Changed():
+			applog.Debugf("配置已更改，重新检查")
+		case r := <-s.requests:
+			r.result <- s.execute(r.mode, r.reason, s.Clock.Now(), 0)
+		case <-s.stop:
+			release()
+			s.stopEscalation()
+			applog.Debugf("doIt函数已停止")
+			return
+		}
+		release()
+	}
+}
+
+// tickPending only verifies the pending escalation step, after scheduling has stopped
+func (s *Scheduler) tickPending() {
+	cfg := s.Config.Get()
+	now := s.Clock.Now().In(cfg.Location())
+	s.evaluated = now
+	s.wall = s.Clock.Now()
+	s.resumed = s.poweredOn()
+	s.next = time.Time{}
+	if !s.tickEscalation(now) {
+		s.tickAfterHooks(cfg)
+	}
+}
+
+// logf writes to the log unless the scheduler only simulates the schedule
+func (s *Scheduler) logf(format string, args ...interface{}) {
+	if s.simulated {
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Stop ends Run
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// ScheduledTime returns the pending operation time, if one has been rolled
+func (s *Scheduler) ScheduledTime() (time.Time, bool) {
+	return s.scheduledShutdownTime, s.shutdownScheduled
+}
+
+// ActiveWindow returns the name of the window the loop is currently in, or ""
+func (s *Scheduler) ActiveWindow() string {
+	return s.activeWindow
+}
+
+// Tick evaluates the schedule once at the clock's current time.
+// It returns false if the user cancelled the warning, which stops the loop.
+func (s *Scheduler) Tick() bool {
+	// 获取当前的关机时间设置
+	cfg := s.Config.Get()
+
+	// 检查系统时间是否被修改，并换算到配置的时区
+	now := s.checkClock(s.Clock.Now(), cfg).In(cfg.Location())
+	s.evaluated = now
+	s.wall = s.Clock.Now()
+	s.resumed = s.poweredOn()
+	s.next = time.Time{}
+
+	// 上次操作的步骤仍在等待确认时不计划新的操作
+	if s.tickEscalation(now) {
+		return true
+	}
+
+	hour := now.Hour()
+	minute := now.Minute()
+	second := now.Second()
+
+	// 检查当前时间是否在某个关机时间窗口内
+	window, inShutdownPeriod := cfg.ActiveWindow(now)
+	currentMode := window.ModeOr(cfg.Mode)
+
+	// 调试模式下记录当前状态
+	if applog.Debug {
+		log.Printf("[DEBUG] 当前时间: %02d:%02d:%02d %s", hour, minute, second, now.Weekday())
+		for _, w := range cfg.Windows {
+			log.Printf("[DEBUG] 时间窗口: %s (%s)", w, power.OperationName(w.ModeOr(cfg.Mode)))
+		}
+		log.Printf("[DEBUG] 警告设置: 启用=%v, 提前时间=%d分钟", cfg.ShowWarning, cfg.WarningMinutes)
+		if e, ok := cfg.Calendar.Lookup(now); ok {
+			log.Printf("[DEBUG] 今日日历例外: %s (%s)", e.Summary, e.Action)
+		}
+		if s.shutdownScheduled {
+			log.Printf("[DEBUG] 已计划关机时间: %s", s.scheduledShutdownTime.Format("15:04:05"))
+			log.Printf("[DEBUG] 距离计划关机还有: %v", s.scheduledShutdownTime.Sub(now))
+		} else {
+			log.Printf("[DEBUG] 尚未计划关机时间")
+		}
+	}
+
+	if applog.Debug {
+		if inShutdownPeriod {
+			rangeKind := "同一天内时间范围"
+			if window.CrossesMidnight() {
+				rangeKind = "跨天时间范围"
+			}
+			log.Printf("[DEBUG] 当前时间在窗口 %s 内 (%s)", window.Name, rangeKind)
+		} else {
+			log.Printf("[DEBUG] 当前时间不在任何时间窗口内")
+		}
+	}
+
+	// 从一个窗口直接进入另一个窗口时重新计划
+	if inShutdownPeriod && s.activeWindow != "" && s.activeWindow != window.Name {
+		applog.Debugf("时间窗口从 %s 切换到 %s", s.activeWindow, window.Name)
+		s.lastEnteredPeriod = time.Time{}
+	}
+	s.activeWindow = window.Name
+	if n, ok := cfg.Night(now); ok {
+		s.night = n
+	}
+
+	// 恢复或重新开机后运行 after 钩子
+	s.tickAfterHooks(cfg)
+
+	// 定时任务独立于时间窗口触发
+	s.tickCron(now, cfg)
+
+	// 远程计划的一次性操作
+	s.tickQueue(now, cfg)
+
+	// 每日屏幕时间配额
+	s.tickQuota(now, cfg)
+
+	// 空闲一段时间后执行操作
+	s.tickIdle(now, cfg)
+
+	// 设置下次定时唤醒
+	s.tickWake(now, cfg)
+
+	// 窗口开始或结束时重新检查
+	s.plan(now, cfg.nextWindowChange(now))
+
+	// 窗口内结束黑名单进程
+	s.setBlocking(inShutdownPeriod && s.Processes != nil && len(cfg.Processes.Block) > 0)
+
+	// 推迟的操作在窗口结束后依然等待执行，避免被拖到窗口外而跳过
+	if !inShutdownPeriod && s.shutdownScheduled && !s.postponedDue.IsZero() {
+		applog.Debugf("窗口已结束，继续等待推迟的%s操作", power.OperationName(s.postponedMode))
+		inShutdownPeriod = true
+		currentMode = s.postponedMode
+	}
+
+	// 如果刚进入时间范围，计算随机关机时间
+	if inShutdownPeriod {
+		// 如果是新进入时间范围，或者上次进入已经超过12小时（防止时钟调整等异常情况）
+		if s.lastEnteredPeriod.IsZero() || now.Sub(s.lastEnteredPeriod) > 12*time.Hour {
+			s.lastEnteredPeriod = now
+			s.shutdownScheduled = false
+			applog.Debugf("新进入时间范围或重置状态")
+		}
+
+		// 本窗口已经执行过操作，只给固定的宽限时间；开机或恢复时记一次尝试
+		if !s.shutdownScheduled {
+			s.applyLockout(now, cfg, currentMode)
+		}
+
+		// 如果还没有计划关机时间，则计算一个随机时间
+		if !s.shutdownScheduled {
+			// 生成一个0-10分钟内的随机延迟
+			randomMinutes := s.Rand.Intn(10) + 1 // 1-10分钟
+			randomSeconds := s.Rand.Intn(60)     // 0-59秒
+			delay := time.Duration(randomMinutes)*time.Minute + time.Duration(randomSeconds)*time.Second
+
+			// 计算关机时间
+			s.scheduledShutdownTime = now.Add(delay)
+			s.shutdownScheduled = true
+
+			s.logf("当前时间 %02d:%02d，在时间窗口 %s 内（%s-%s）\n",
+				hour, minute, window.Name, window.Start, window.End)
+			s.logf("已计划在 %s 执行%s操作（随机延迟%d分%d秒）\n",
+				s.scheduledShutdownTime.Format("15:04:05"), power.OperationName(currentMode), randomMinutes, randomSeconds)
+
+			if applog.Debug {
+				log.Printf("[DEBUG] 计算了新的关机时间: %s", s.scheduledShutdownTime.Format("15:04:05"))
+				log.Printf("[DEBUG] 随机延迟: %d分%d秒", randomMinutes, randomSeconds)
+				if cfg.ShowWarning {
+					// 计算警告时间
+					warningTime := s.scheduledShutdownTime.Add(-time.Duration(cfg.WarningMinutes) * time.Minute)
+					log.Printf("[DEBUG] 警告将在 %s 显示（提前%d分钟）",
+						warningTime.Format("15:04:05"), cfg.WarningMinutes)
+				} else {
+					log.Printf("[DEBUG] 警告功能已禁用")
+				}
+			}
+		}
+
+		// 如果已经到了计划的关机时间，执行关机
+		if s.shutdownScheduled {
+			// 计算警告时间
+			warningTime := s.scheduledShutdownTime.Add(-time.Duration(cfg.WarningMinutes) * time.Minute)
+
+			// 如果启用了警告并且当前时间已过警告时间但还未到关机时间
+			if cfg.ShowWarning && !now.Before(warningTime) && now.Before(s.scheduledShutdownTime) && !s.warningShown {
+				applog.Debugf("当前时间 %s 已过警告时间 %s，准备显示警告",
+					now.Format("15:04:05"), warningTime.Format("15:04:05"))
+
+				// 计算实际剩余时间（分钟）
+				remainMinutes := int(s.scheduledShutdownTime.Sub(now).Minutes())
+				// 如果剩余时间小于1分钟，至少显示1分钟
+				if remainMinutes < 1 {
+					remainMinutes = 1
+				}
+				applog.Debugf("实际剩余时间: %d分钟", remainMinutes)
+
+				// 显示警告对话框，传入实际剩余时间
+				warningResult := s.warnWindow(now, cfg, currentMode, remainMinutes)
+				s.warningShown = true
+
+				applog.Debugf("警告对话框结果: %v", warningResult)
+
+				// 如果用户取消了操作
+				if !warningResult {
+					log.Printf(i18n.T("shutdown_cancelled", power.OperationName(currentMode)))
+					s.shutdownScheduled = false
+					s.lastEnteredPeriod = time.Time{} // 重置为零值
+					s.postponedDue = time.Time{}
+					s.saveState()
+					return false
+				}
+			}
+
+			// 已批准的延长推后计划时间
+			s.applyExtensions(now)
+
+			// 到警告时间和计划时间时再次检查
+			if cfg.ShowWarning && !s.warningShown {
+				s.plan(now, s.scheduledShutdownTime.Add(-time.Duration(cfg.WarningMinutes)*time.Minute))
+			}
+			s.plan(now, s.scheduledShutdownTime)
+
+			// 如果已经到了计划的关机时间
+			if !now.Before(s.scheduledShutdownTime) {
+				// 白名单进程运行或系统繁忙时推迟，直到结束或达到最长推迟时间
+				if s.postponedDue.IsZero() {
+					s.postponedDue = s.scheduledShutdownTime
+				}
+				if s.deferForProcesses(now, cfg, currentMode, "schedule", s.postponedDue) ||
+					s.postpone(now, cfg, currentMode, "schedule", s.postponedDue) {
+					s.postponedMode = currentMode
+					s.scheduledShutdownTime = now.Add(PostponeRecheck)
+					s.plan(now, s.scheduledShutdownTime)
+					s.saveState()
+					return true
+				}
+				s.postponedDue = time.Time{}
+
+				// 重置警告标志，为下一次关机做准备
+				s.warningShown = false
+				s.logf("当前时间 %02d:%02d，已到计划的时间，执行%s操作\n",
+					hour, minute, power.OperationName(currentMode))
+
+				applog.Debugf("准备执行%s操作", power.OperationName(currentMode))
+
+				// 先重置并保存状态，关机后重启不会立即再次执行
+				s.shutdownScheduled = false
+				s.lastEnteredPeriod = time.Time{} // 重置为零值
+				s.saveState()
+				s.expireExtensions(now)
+				s.recordEnforcement(now, cfg)
+				s.perform(currentMode, "schedule", s.scheduledShutdownTime, window.ForceAfter())
+
+				// 电脑没有停下时再次检查，否则只有窗口结束时才会醒来
+				if s.pending == nil {
+					s.plan(now, now.Add(OperationRecheck))
+				}
+			}
+		}
+	} else {
+		// 如果不在时间范围内，重置状态
+		if s.shutdownScheduled {
+			applog.Debugf("不在时间范围内，重置关机计划")
+		}
+		s.shutdownScheduled = false
+		s.lastEnteredPeriod = time.Time{} // 重置为零值
+		s.postponedDue = time.Time{}
+		// 窗口结束前没有执行操作时，下一个窗口仍要显示警告
+		s.warningShown = false
+	}
+	s.saveState()
+	return true
+}
+
+// 根据操作模式执行相应操作，reason 记录触发操作的来源，scheduled 是计划的执行时间
+func (s *Scheduler) PerformOperation(mode, reason string, scheduled time.Time) {
+	s.perform(mode, reason, scheduled, 0)
+}
+
+// perform 执行操作，force 大于零时正常关机或重启在这段时间后仍未生效则强制执行
+func (s *Scheduler) perform(mode, reason string, scheduled time.Time, force time.Duration) {
+	cfg := s.Config.Get()
+
+	// 如果启用了警告，则显示警告对话框
+	if cfg.ShowWarning && cfg.WarningMinutes > 0 {
+		applog.Debugf("显示关机前警告对话框，操作模式: %s, 提前时间: %d分钟",
+			power.OperationName(mode), cfg.WarningMinutes)
+
+		// 显示警告对话框
+		warningResult := s.Warn(mode, cfg.WarningMinutes)
+
+		applog.Debugf("警告对话框结果: %v (真=继续, 假=取消)", warningResult)
+
+		if !warningResult {
+			// 用户取消了操作
+			log.Printf(i18n.T("shutdown_cancelled", power.OperationName(mode)))
+			return
+		}
+	} else {
+		applog.Debugf("跳过警告对话框，警告功能已禁用或提前时间为0")
+	}
+
+	s.execute(mode, reason, scheduled, force)
+}
+
+// execute 运行钩子并通过升级链执行操作，不显示警告。
+// 钩子取消操作或所有步骤都被拒绝时返回错误，错误已写入日志
+func (s *Scheduler) execute(mode, reason string, scheduled time.Time, force time.Duration) error {
+	cfg := s.Config.Get()
+
+	// before 钩子失败且策略为 abort 时取消操作
+	e := hook.Event{Phase: hook.Before, Mode: mode, Reason: reason, Scheduled: scheduled}
+	if !s.runHooks(cfg.Hooks.Before, e) {
+		log.Printf(i18n.T("log_hook_aborted", power.OperationName(mode)))
+		return errors.New(i18n.T("log_hook_aborted", power.OperationName(mode)))
+	}
+
+	// 记录 after 钩子，睡眠或休眠后在恢复时运行，关机或重启后在下次启动时运行
+	if len(cfg.Hooks.After) > 0 {
+		e.Phase = hook.After
+		e.Performed = s.Clock.Now()
+		s.afterHooks = e
+		s.saveState()
+	}
+
+	applog.Debugf("准备执行操作: %s", power.OperationName(mode))
+
+	if err := s.escalate(mode, reason, cfg, force); err != nil {
+		s.operationFailed(mode, err)
+		return err
+	}
+	// 电脑没有停下的操作（例如注销、锁定）之后立即运行
+	if s.afterHooks.Phase != "" && s.stays(mode) {
+		s.runAfterHooks(cfg)
+	}
+	return nil
+}
+
+// operationFailed logs that mode did not run and drops its "after" hooks
+func (s *Scheduler) operationFailed(mode string, err error) {
+	log.Printf(i18n.T("operation_failed", power.OperationName(mode), err))
+	s.afterHooks = hook.Event{}
+	s.saveState()
+}
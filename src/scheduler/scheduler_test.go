@@ -0,0 +1,283 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/power"
+)
+
+// nightSettings returns settings with a single 22:00-06:00 window in UTC
+func nightSettings(t *testing.T, mode string, warning bool) Settings {
+	t.Helper()
+	s := Settings{
+		Mode:           mode,
+		ShowWarning:    warning,
+		WarningMinutes: 5,
+		TimeZone:       "UTC",
+		Windows: []Window{
+			{Name: "night", Start: TimeOfDay{Hour: 22}, End: TimeOfDay{Hour: 6}},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	return s
+}
+
+// newTestScheduler returns a scheduler on a fake clock at start with a dry-run backend.
+// The random delay is taken from values.
+func newTestScheduler(cfg Settings, start time.Time, values ...int) (*Scheduler, *FakeClock, *power.DryRun) {
+	clock := NewFakeClock(start)
+	backend := power.NewDryRun()
+	backend.Now = clock.Now
+	s := New(NewConfig(cfg), nil, backend)
+	s.Clock = clock
+	s.Rand = &SequenceRand{Values: values}
+	return s, clock, backend
+}
+
+func TestDryRunTick(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(nightSettings(t, "shutdown", false), start, 2, 30)
+
+	s.Tick()
+	if at, ok := s.ScheduledTime(); !ok || !at.Equal(start.Add(3*time.Minute+30*time.Second)) {
+		t.Fatalf("ScheduledTime() = %v, %v, want 22:03:30", at, ok)
+	}
+	if n := len(backend.Records()); n != 0 {
+		t.Fatalf("%d operations recorded before the scheduled time", n)
+	}
+
+	clock.Advance(3*time.Minute + 30*time.Second)
+	s.Tick()
+	records := backend.Records()
+	if len(records) != 1 {
+		t.Fatalf("Records() = %+v, want one operation", records)
+	}
+	want := power.Record{Time: start.Add(3*time.Minute + 30*time.Second), Mode: "shutdown", Reason: "schedule"}
+	if r := records[0]; !r.Time.Equal(want.Time) || r.Mode != want.Mode || r.Reason != want.Reason {
+		t.Errorf("Records()[0] = %+v, want %+v", r, want)
+	}
+}
+
+// runUntil drives the loop like Run: it ticks and advances the clock to the next
+// planned instant until end, or until the first operation is recorded
+func runUntil(t *testing.T, s *Scheduler, clock *FakeClock, backend *power.DryRun, end time.Time) {
+	t.Helper()
+	for clock.Now().Before(end) && len(backend.Records()) == 0 {
+		if !s.Tick() {
+			t.Fatalf("Tick() stopped the loop at %v", clock.Now())
+		}
+		at := s.wakeAt()
+		if at.IsZero() || !at.After(clock.Now()) {
+			t.Fatalf("loop would wake at %v at %v", at, clock.Now())
+		}
+		clock.Advance(at.Sub(clock.Now()))
+	}
+}
+
+func TestTickNightWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Time
+		values  []int
+		warning time.Time
+		perform time.Time
+	}{
+		{
+			name:    "shortest delay",
+			start:   time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC),
+			values:  []int{0, 0},
+			warning: time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC),
+			perform: time.Date(2026, 10, 19, 22, 1, 0, 0, time.UTC),
+		},
+		{
+			name:    "longest delay",
+			start:   time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC),
+			values:  []int{9, 59},
+			warning: time.Date(2026, 10, 19, 22, 5, 59, 0, time.UTC),
+			perform: time.Date(2026, 10, 19, 22, 10, 59, 0, time.UTC),
+		},
+		{
+			name:    "powered on after midnight",
+			start:   time.Date(2026, 10, 20, 1, 30, 0, 0, time.UTC),
+			values:  []int{6, 15},
+			warning: time.Date(2026, 10, 20, 1, 32, 15, 0, time.UTC),
+			perform: time.Date(2026, 10, 20, 1, 37, 15, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clock, backend := newTestScheduler(nightSettings(t, "hibernate", true), tt.start, tt.values...)
+			var warnings []time.Time
+			s.Warn = func(mode string, minutes int) bool {
+				warnings = append(warnings, clock.Now())
+				return true
+			}
+
+			runUntil(t, s, clock, backend, tt.start.Add(24*time.Hour))
+
+			// 第一次是窗口内的警告，第二次是执行前的确认
+			if len(warnings) != 2 || !warnings[0].Equal(tt.warning) || !warnings[1].Equal(tt.perform) {
+				t.Errorf("warnings at %v, want %v and %v", warnings, tt.warning, tt.perform)
+			}
+			records := backend.Records()
+			if len(records) != 1 || !records[0].Time.Equal(tt.perform) || records[0].Mode != "hibernate" {
+				t.Fatalf("Records() = %+v, want hibernate at %v", records, tt.perform)
+			}
+		})
+	}
+}
+
+func TestTickOutsideWindow(t *testing.T) {
+	start := time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(nightSettings(t, "shutdown", false), start)
+
+	s.Tick()
+	if _, ok := s.ScheduledTime(); ok {
+		t.Errorf("operation scheduled outside the window")
+	}
+	// 窗口外直接睡到下一个窗口开始
+	if at, want := s.wakeAt(), time.Date(2026, 10, 20, 22, 0, 0, 0, time.UTC); !at.Equal(want) {
+		t.Errorf("wakeAt() = %v, want %v", at, want)
+	}
+	runUntil(t, s, clock, backend, time.Date(2026, 10, 20, 21, 59, 0, 0, time.UTC))
+	if n := len(backend.Records()); n != 0 {
+		t.Errorf("%d operations recorded outside the window", n)
+	}
+}
+
+func TestTickCancelledWarning(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, _, backend := newTestScheduler(nightSettings(t, "shutdown", true), start, 0, 0)
+	s.Warn = func(mode string, minutes int) bool { return false }
+
+	if s.Tick() {
+		t.Errorf("Tick() = true after the warning was cancelled")
+	}
+	if _, ok := s.ScheduledTime(); ok || len(backend.Records()) != 0 {
+		t.Errorf("operation still scheduled or performed after cancelling")
+	}
+}
+
+func TestTickStaleEntryReset(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	s, clock, backend := newTestScheduler(nightSettings(t, "shutdown", false), start, 9, 59, 3, 0)
+
+	s.Tick()
+	if at, _ := s.ScheduledTime(); !at.Equal(start.Add(10*time.Minute + 59*time.Second)) {
+		t.Fatalf("ScheduledTime() = %v, want 22:10:59", at)
+	}
+
+	// 电脑在计划时间前挂起，第二天晚上才恢复：上次进入窗口已超过 12 小时，
+	// 不能立即执行过期的计划，而是重新计算随机时间
+	resumed := start.Add(24*time.Hour + 30*time.Minute)
+	clock.Set(resumed)
+	s.Tick()
+	if n := len(backend.Records()); n != 0 {
+		t.Fatalf("%d operations performed right after resuming", n)
+	}
+	if at, ok := s.ScheduledTime(); !ok || !at.Equal(resumed.Add(4*time.Minute)) {
+		t.Errorf("ScheduledTime() = %v, %v, want a new delay from %v", at, ok, resumed)
+	}
+
+	// 12 小时之内恢复则保留原来的计划
+	s, clock, _ = newTestScheduler(nightSettings(t, "shutdown", false), start, 9, 59, 3, 0)
+	s.Tick()
+	clock.Set(start.Add(5 * time.Minute))
+	s.Tick()
+	if at, _ := s.ScheduledTime(); !at.Equal(start.Add(10*time.Minute + 59*time.Second)) {
+		t.Errorf("ScheduledTime() = %v after 5 minutes, want the original 22:10:59", at)
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	early, late := clock.After(time.Minute), clock.After(time.Hour)
+
+	clock.Advance(2 * time.Minute)
+	select {
+	case at := <-early:
+		if !at.Equal(start.Add(2 * time.Minute)) {
+			t.Errorf("timer fired with %v", at)
+		}
+	default:
+		t.Errorf("1m timer did not fire after 2m")
+	}
+	select {
+	case <-late:
+		t.Errorf("1h timer fired after 2m")
+	default:
+	}
+	if clock.Waiters() != 1 || clock.Monotonic() != 2*time.Minute {
+		t.Errorf("Waiters() = %d, Monotonic() = %v", clock.Waiters(), clock.Monotonic())
+	}
+
+	// Set 只改墙上时间，单调时钟不变
+	clock.Set(start.Add(2 * time.Hour))
+	if clock.Waiters() != 0 || clock.Monotonic() != 2*time.Minute {
+		t.Errorf("after Set: Waiters() = %d, Monotonic() = %v", clock.Waiters(), clock.Monotonic())
+	}
+}
+
+func TestFakeClockAlarm(t *testing.T) {
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	fired := func(c <-chan time.Time) bool {
+		select {
+		case <-c:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// 到达时间时触发
+	due, _ := clock.Alarm(start.Add(time.Hour))
+	clock.Advance(30 * time.Minute)
+	if fired(due) {
+		t.Error("alarm fired before its time")
+	}
+	clock.Advance(30 * time.Minute)
+	if !fired(due) {
+		t.Error("alarm did not fire at its time")
+	}
+
+	// 修改墙上时间或从挂起恢复时提前触发
+	set, _ := clock.Alarm(start.Add(5 * time.Hour))
+	clock.Set(start.Add(-time.Hour))
+	if !fired(set) {
+		t.Error("alarm did not fire when the clock was set")
+	}
+	resumed, _ := clock.Alarm(time.Time{})
+	clock.Advance(time.Hour)
+	if fired(resumed) {
+		t.Error("alarm without a time fired on Advance")
+	}
+	clock.Suspend(time.Hour)
+	if !fired(resumed) {
+		t.Error("alarm did not fire on resume")
+	}
+	if clock.Suspended() != time.Hour {
+		t.Errorf("Suspended() = %v, want 1h", clock.Suspended())
+	}
+
+	// 释放后不再触发
+	released, stop := clock.Alarm(time.Time{})
+	stop()
+	clock.Suspend(time.Hour)
+	if fired(released) || clock.Waiters() != 0 {
+		t.Errorf("released alarm fired or is still waiting")
+	}
+}
+
+func TestSequenceRand(t *testing.T) {
+	r := &SequenceRand{Values: []int{3, 12, -1}}
+	for i, want := range []int{3, 9, 0, 0} {
+		if got := r.Intn(10); got != want {
+			t.Errorf("Intn #%d = %d, want %d", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+// MaxSimulationDays limits the range of a simulation
+const MaxSimulationDays = 31
+
+// SimulatedEntry is one window occurrence the machine enters during a simulation,
+// with the earliest and latest times allowed by the random delay
+type SimulatedEntry struct {
+	Window     string
+	Entered    time.Time // Window start, or the start of the simulation inside a window
+	Start, End time.Time // The window occurrence
+	Mode       string
+
+	// Operation times for the shortest and longest delay; zero if the window
+	// ends first and the operation is skipped
+	Earliest, Latest time.Time
+	// Warning times for the shortest and longest delay; zero without a warning
+	WarnEarliest, WarnLatest time.Time
+}
+
+// simulatedRun is what one pass of the loop saw, with a fixed random delay
+type simulatedRun struct {
+	entries []SimulatedEntry
+	pending *SimulatedEntry
+}
+
+// simulationBackend records the operations of a simulated run. It implements
+// power.Recorder like the dry-run backend, so the operations are not verified.
+type simulationBackend struct {
+	clock *FakeClock
+	ops   []power.Record
+}
+
+func (b *simulationBackend) Perform(op power.Operation) error {
+	b.ops = append(b.ops, power.Record{Time: b.clock.Now(), Mode: op.Mode, Reason: op.Reason})
+	return nil
+}
+
+func (b *simulationBackend) Records() []power.Record {
+	return b.ops
+}
+
+// Simulate runs the scheduler loop on a fake clock from from to to, assuming the
+// machine is on whenever a window starts and stays off for the rest of the window
+// once the operation ran. It runs twice, with the shortest and the longest random
+// delay. Cron triggers, idle policies, quotas, one-off operations, busy and process
+// checks, extensions, lockout and hooks are left out.
+func Simulate(cfg Settings, from, to time.Time) []SimulatedEntry {
+	cfg.Cron = nil
+	cfg.Idle = nil
+	cfg.Hooks.Before, cfg.Hooks.After = nil, nil
+
+	short := simulateRun(cfg, from, to, &SequenceRand{Values: []int{0, 0}})
+	long := simulateRun(cfg, from, to, &SequenceRand{Values: []int{9, 59}})
+
+	entries := short.entries
+	for i := range entries {
+		e := &entries[i]
+		e.Latest, e.WarnLatest = time.Time{}, time.Time{}
+		for _, l := range long.entries {
+			if l.Window == e.Window && l.Entered.Equal(e.Entered) {
+				e.Latest, e.WarnLatest = l.Earliest, l.WarnEarliest
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// simulateRun runs the loop once. Every Tick rolls the same delay from rand.
+func simulateRun(cfg Settings, from, to time.Time, rand *SequenceRand) simulatedRun {
+	clock := NewFakeClock(from)
+	run := &simulatedRun{}
+	backend := &simulationBackend{clock: clock}
+	values := rand.Values
+
+	var warnings []time.Time
+	s := New(NewConfig(cfg), func(mode string, minutes int) bool {
+		warnings = append(warnings, clock.Now())
+		return true
+	}, backend)
+	s.Clock = clock
+	s.Rand = rand
+	s.simulated = true
+
+	// 结束时仍在窗口内的话，继续运行到操作执行或窗口结束
+	for clock.Now().Before(to) || run.pending != nil {
+		rand.Values = append([]int(nil), values...)
+		ops, warned := len(backend.ops), len(warnings)
+		s.Tick()
+		now := clock.Now().In(cfg.Location())
+
+		// 新进入窗口并计划了随机时间
+		if s.shutdownScheduled && s.lastEnteredPeriod.Equal(now) && (run.pending == nil || !run.pending.Entered.Equal(now)) {
+			run.finish()
+			w, day, _ := cfg.activeOccurrence(now)
+			start, end := w.Occurrence(day)
+			run.pending = &SimulatedEntry{Window: w.Name, Entered: now, Start: start, End: end, Mode: w.ModeOr(cfg.Mode)}
+		}
+
+		// 只记录窗口的第一次警告，执行操作时的再次确认不算
+		if len(warnings) > warned && run.pending != nil && run.pending.WarnEarliest.IsZero() {
+			run.pending.WarnEarliest = warnings[warned]
+		}
+
+		// 执行操作后电脑关闭，直到窗口结束
+		if len(backend.ops) > ops && run.pending != nil {
+			op := backend.ops[len(backend.ops)-1]
+			run.pending.Earliest, run.pending.Mode = op.Time, op.Mode
+			end := run.pending.End
+			run.finish()
+			if end.After(now) {
+				clock.Advance(end.Sub(now))
+				continue
+			}
+		}
+		if run.pending != nil && !now.Before(run.pending.End) {
+			run.finish()
+		}
+
+		if s.next.IsZero() {
+			break
+		}
+		clock.Advance(s.next.Sub(now))
+	}
+	run.finish()
+	return *run
+}
+
+// finish closes the entry of the current window
+func (r *simulatedRun) finish() {
+	if r.pending != nil {
+		r.entries = append(r.entries, *r.pending)
+		r.pending = nil
+	}
+}
+
+// ParseSimulationRange parses the arguments of the simulate command: an optional
+// start date (2006-01-02, default today) and an optional number of days (default 7)
+func ParseSimulationRange(args []string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	now = now.In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	days := 7
+	for _, arg := range args {
+		if d, err := time.ParseInLocation("2006-01-02", arg, loc); err == nil {
+			from = d
+		} else if n, err := strconv.Atoi(arg); err == nil && n > 0 && n <= MaxSimulationDays {
+			days = n
+		} else {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid argument %q", arg)
+		}
+	}
+	// 从今天开始时不模拟已经过去的时间
+	if from.Before(now) && from.Add(24*time.Hour).After(now) {
+		return now, from.AddDate(0, 0, days), nil
+	}
+	return from, from.AddDate(0, 0, days), nil
+}
+
+// FormatSimulation describes the simulated entries for the simulate command
+func FormatSimulation(entries []SimulatedEntry, from, to time.Time, loc *time.Location) string {
+	lines := []string{i18n.T("simulate_header", from.In(loc).Format("2006-01-02 15:04"), to.In(loc).Format("2006-01-02 15:04"), loc)}
+	if len(entries) == 0 {
+		return strings.Join(append(lines, i18n.T("simulate_none")), "\n")
+	}
+	for _, e := range entries {
+		lines = append(lines, i18n.T("simulate_entry", e.Entered.In(loc).Format("Mon 2006-01-02 15:04"), e.Window,
+			e.Start.In(loc).Format("15:04"), e.End.In(loc).Format("15:04"), power.OperationName(e.Mode)))
+		if !e.WarnEarliest.IsZero() {
+			lines = append(lines, i18n.T("simulate_warning", simulatedRange(e.WarnEarliest, e.WarnLatest, loc)))
+		}
+		switch {
+		case e.Earliest.IsZero():
+			lines = append(lines, i18n.T("simulate_skipped"))
+		case e.Latest.IsZero():
+			lines = append(lines, i18n.T("simulate_maybe", simulatedRange(e.Earliest, e.End, loc)))
+		default:
+			lines = append(lines, i18n.T("simulate_operation", simulatedRange(e.Earliest, e.Latest, loc)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func simulatedRange(earliest, latest time.Time, loc *time.Location) string {
+	if latest.IsZero() || latest.Equal(earliest) {
+		return earliest.In(loc).Format("15:04:05")
+	}
+	return earliest.In(loc).Format("15:04:05") + " - " + latest.In(loc).Format("15:04:05")
+}
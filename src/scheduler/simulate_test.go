@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/power"
+)
+
+func TestParseSimulationRange(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+	now := at(time.October, 18, 14, 30)
+
+	tests := []struct {
+		name     string
+		args     []string
+		from, to time.Time
+	}{
+		{"default", nil, now, at(time.October, 25, 0, 0)},
+		{"days", []string{"3"}, now, at(time.October, 21, 0, 0)},
+		{"most days", []string{"31"}, now, at(time.November, 18, 0, 0)},
+		{"today", []string{"2026-10-18"}, now, at(time.October, 25, 0, 0)},
+		{"future date", []string{"2026-12-20"}, at(time.December, 20, 0, 0), at(time.December, 27, 0, 0)},
+		{"date and days", []string{"2026-12-20", "14"}, at(time.December, 20, 0, 0), time.Date(2027, time.January, 3, 0, 0, 0, 0, loc)},
+		{"days and date", []string{"2", "2026-12-20"}, at(time.December, 20, 0, 0), at(time.December, 22, 0, 0)},
+		{"past date", []string{"2026-10-10"}, at(time.October, 10, 0, 0), at(time.October, 17, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := ParseSimulationRange(tt.args, now, loc)
+			if err != nil {
+				t.Fatalf("ParseSimulationRange(%q) error: %v", tt.args, err)
+			}
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("ParseSimulationRange(%q) = %v - %v, want %v - %v", tt.args, from, to, tt.from, tt.to)
+			}
+		})
+	}
+
+	// 开始时间按 loc 解释，与 now 所在的时区无关
+	from, _, err := ParseSimulationRange(nil, now.UTC(), loc)
+	if err != nil || !from.Equal(now) || from.Location() != loc {
+		t.Errorf("ParseSimulationRange() with a UTC now = %v, %v, want %v", from, err, now)
+	}
+
+	for _, args := range [][]string{{"0"}, {"32"}, {"-1"}, {"tomorrow"}, {"2026-13-01"}, {"2026-12-20", "x"}} {
+		if _, _, err := ParseSimulationRange(args, now, loc); err == nil {
+			t.Errorf("ParseSimulationRange(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+func TestFormatSimulation(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, second, 0, loc)
+	}
+	from, to := at(18, 14, 30, 0), at(25, 0, 0, 0)
+	header := i18n.T("simulate_header", "2026-10-18 14:30", "2026-10-25 00:00", loc)
+
+	if got, want := FormatSimulation(nil, from, to, loc), header+"\n"+i18n.T("simulate_none"); got != want {
+		t.Errorf("FormatSimulation() without entries = %q, want %q", got, want)
+	}
+
+	entries := []SimulatedEntry{
+		{
+			Window: "night", Entered: at(19, 22, 0, 0), Start: at(19, 22, 0, 0), End: at(20, 6, 0, 0), Mode: "shutdown",
+			Earliest: at(19, 22, 6, 0), Latest: at(19, 22, 15, 30),
+			WarnEarliest: at(19, 22, 1, 0), WarnLatest: at(19, 22, 10, 30),
+		},
+		{
+			// 模拟从窗口中间开始，没有随机延迟
+			Window: "night", Entered: at(20, 3, 0, 0), Start: at(19, 22, 0, 0), End: at(20, 6, 0, 0), Mode: "suspend",
+			Earliest: at(20, 3, 0, 5), Latest: at(20, 3, 0, 5),
+		},
+		{
+			Window: "short", Entered: at(21, 23, 55, 0), Start: at(21, 23, 55, 0), End: at(22, 0, 0, 0), Mode: "hibernate",
+			Earliest: at(21, 23, 56, 0),
+		},
+		{
+			Window: "short", Entered: at(22, 23, 55, 0), Start: at(22, 23, 55, 0), End: at(23, 0, 0, 0), Mode: "hibernate",
+		},
+	}
+	want := []string{
+		header,
+		i18n.T("simulate_entry", at(19, 22, 0, 0).Format("Mon 2006-01-02 15:04"), "night", "22:00", "06:00", power.OperationName("shutdown")),
+		i18n.T("simulate_warning", "22:01:00 - 22:10:30"),
+		i18n.T("simulate_operation", "22:06:00 - 22:15:30"),
+		i18n.T("simulate_entry", at(20, 3, 0, 0).Format("Mon 2006-01-02 15:04"), "night", "22:00", "06:00", power.OperationName("suspend")),
+		i18n.T("simulate_operation", "03:00:05"),
+		i18n.T("simulate_entry", at(21, 23, 55, 0).Format("Mon 2006-01-02 15:04"), "short", "23:55", "00:00", power.OperationName("hibernate")),
+		i18n.T("simulate_maybe", "23:56:00 - 00:00:00"),
+		i18n.T("simulate_entry", at(22, 23, 55, 0).Format("Mon 2006-01-02 15:04"), "short", "23:55", "00:00", power.OperationName("hibernate")),
+		i18n.T("simulate_skipped"),
+	}
+	got := strings.Split(FormatSimulation(entries, from, to, loc), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("FormatSimulation() returned %d lines, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// 时间按 loc 显示
+	utc := FormatSimulation(entries[:1], from.UTC(), to.UTC(), loc)
+	if !strings.HasPrefix(utc, header+"\n") || !strings.Contains(utc, "22:06:00 - 22:15:30") {
+		t.Errorf("FormatSimulation() with UTC times =\n%s", utc)
+	}
+}
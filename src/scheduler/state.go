@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/fsutil"
+	"codans.com/autoshut/src/hook"
+	"codans.com/autoshut/src/i18n"
+	"codans.com/autoshut/src/tamper"
+)
+
+// state is the part of the scheduler that survives a service restart, so
+// restarting inside a window keeps the rolled time and the warning progress
+type state struct {
+	ShutdownScheduled     bool      `json:"shutdown_scheduled"`
+	ScheduledShutdownTime time.Time `json:"scheduled_time"`
+	LastEnteredPeriod     time.Time `json:"last_entered_period"`
+	WarningShown          bool      `json:"warning_shown"`
+	ActiveWindow          string    `json:"window,omitempty"`
+	Night                 string    `json:"night,omitempty"`
+	PostponedDue          time.Time `json:"postponed_due,omitempty"`
+	PostponedMode         string    `json:"postponed_mode,omitempty"`
+
+	// Operation whose "after" hooks have not run yet
+	AfterHooks hook.Event `json:"after_hooks,omitempty"`
+
+	// Clock baseline, so a clock change while the service was stopped is still detected
+	Tamper tamper.State `json:"tamper"`
+}
+
+// snapshot returns the current persistent state
+func (s *Scheduler) snapshot() state {
+	var clock tamper.State
+	if s.Tamper != nil {
+		clock = s.Tamper.State()
+	}
+	return state{
+		ShutdownScheduled:     s.shutdownScheduled,
+		ScheduledShutdownTime: s.scheduledShutdownTime,
+		LastEnteredPeriod:     s.lastEnteredPeriod,
+		WarningShown:          s.warningShown,
+		ActiveWindow:          s.activeWindow,
+		Night:                 s.night,
+		PostponedDue:          s.postponedDue,
+		PostponedMode:         s.postponedMode,
+		AfterHooks:            s.afterHooks,
+		Tamper:                clock,
+	}
+}
+
+// LoadState restores the state saved in StatePath. A missing file is not an error.
+// Stale state is cleaned up by the next Tick like after any long pause.
+func (s *Scheduler) LoadState() error {
+	if s.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+
+	s.shutdownScheduled = st.ShutdownScheduled
+	s.scheduledShutdownTime = st.ScheduledShutdownTime
+	s.lastEnteredPeriod = st.LastEnteredPeriod
+	s.warningShown = st.WarningShown
+	s.activeWindow = st.ActiveWindow
+	s.night = st.Night
+	s.postponedDue = st.PostponedDue
+	s.postponedMode = st.PostponedMode
+	s.afterHooks = st.AfterHooks
+	s.saved = st
+	if s.Tamper != nil && !s.Tamper.Restore(st.Tamper, s.Clock.Monotonic()) {
+		// 重启后无法判断停机期间时间是否被修改，重新建立基准
+		applog.Debugf("系统已重启，时间基准重新建立")
+		s.saved.Tamper = tamper.State{}
+	}
+
+	if st.ShutdownScheduled {
+		log.Printf(i18n.T("log_state_restored", st.ScheduledShutdownTime.Format("2006-01-02 15:04:05")))
+	}
+	return nil
+}
+
+// saveState writes the state to StatePath if it changed since the last save
+func (s *Scheduler) saveState() {
+	if s.StatePath == "" {
+		return
+	}
+	st := s.snapshot()
+	if st == s.saved {
+		return
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err == nil {
+		err = fsutil.WriteFileAtomic(s.StatePath, data, 0644)
+	}
+	if err != nil {
+		log.Printf(i18n.T("log_state_save_failed", err))
+		return
+	}
+	applog.Debugf("调度状态已保存: %+v", st)
+	s.saved = st
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codans.com/autoshut/src/power"
+)
+
+// restartScheduler returns a new scheduler at now that loads the state saved in path,
+// like the service after a restart. Its random delay would differ from the saved one.
+func restartScheduler(t *testing.T, cfg Settings, path string, now time.Time) (*Scheduler, *FakeClock, *[]time.Time) {
+	t.Helper()
+	s, clock, _ := newTestScheduler(cfg, now, 0, 0)
+	s.StatePath = path
+	if err := s.LoadState(); err != nil {
+		t.Fatalf("LoadState() = %v", err)
+	}
+	warnings := &[]time.Time{}
+	s.Warn = func(mode string, minutes int) bool {
+		*warnings = append(*warnings, clock.Now())
+		return true
+	}
+	return s, clock, warnings
+}
+
+func TestStateKeepsDeadline(t *testing.T) {
+	cfg := nightSettings(t, "shutdown", true)
+	start := time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)
+	deadline := start.Add(7 * time.Minute)
+
+	tests := []struct {
+		name     string
+		stopAt   time.Time
+		warnings []time.Time
+	}{
+		// 警告还没显示，重启后按原来的时间显示
+		{"before the warning", start.Add(time.Minute), []time.Time{start.Add(2 * time.Minute), deadline}},
+		// 警告已经显示，重启后只剩执行前的确认
+		{"after the warning", start.Add(3 * time.Minute), []time.Time{deadline}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scheduler.json")
+			s, clock, backend := newTestScheduler(cfg, start, 6, 0)
+			s.StatePath = path
+			s.Warn = func(mode string, minutes int) bool { return true }
+			s.Tick()
+			clock.Advance(tt.stopAt.Sub(start))
+			s.Tick()
+			if n := len(backend.Records()); n != 0 {
+				t.Fatalf("%d operations before the restart", n)
+			}
+
+			s, clock, warnings := restartScheduler(t, cfg, path, tt.stopAt.Add(30*time.Second))
+			if at, ok := s.ScheduledTime(); !ok || !at.Equal(deadline) {
+				t.Fatalf("ScheduledTime() after the restart = %v, %v, want %v", at, ok, deadline)
+			}
+			backend = s.Backend.(*power.DryRun)
+			runUntil(t, s, clock, backend, start.Add(time.Hour))
+			if r := backend.Records(); len(r) != 1 || !r[0].Time.Equal(deadline) {
+				t.Fatalf("Records() = %+v, want one operation at %v", r, deadline)
+			}
+			if w := *warnings; len(w) != len(tt.warnings) {
+				t.Fatalf("warnings at %v, want %v", w, tt.warnings)
+			}
+			for i, want := range tt.warnings {
+				if w := (*warnings)[i]; !w.Equal(want) {
+					t.Errorf("warning %d at %v, want %v", i, w, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadStateMissingOrInvalid(t *testing.T) {
+	dir := t.TempDir()
+	s, _, _ := newTestScheduler(nightSettings(t, "shutdown", false), time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+
+	s.StatePath = filepath.Join(dir, "missing.json")
+	if err := s.LoadState(); err != nil {
+		t.Errorf("LoadState() without a file = %v, want nil", err)
+	}
+	if _, ok := s.ScheduledTime(); ok {
+		t.Error("ScheduledTime() is set without a saved state")
+	}
+
+	s.StatePath = filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(s.StatePath, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadState(); err == nil {
+		t.Error("LoadState() with an invalid file succeeded, want an error")
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"codans.com/autoshut/src/applog"
+	"codans.com/autoshut/src/solar"
+)
+
+// Coordinates locate the machine for sunrise and sunset based windows
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`  // Degrees, north positive
+	Longitude float64 `json:"longitude"` // Degrees, east positive
+}
+
+// validateSolar checks that sun-relative times have coordinates to be resolved with
+func (s *Settings) validateSolar() error {
+	if c := s.Coordinates; c != nil {
+		if c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180 {
+			return fmt.Errorf("coordinates out of range: %v, %v", c.Latitude, c.Longitude)
+		}
+	}
+	for _, w := range s.Windows {
+		if w.IsSolar() && s.Coordinates == nil {
+			return fmt.Errorf("window %s: sunrise and sunset need coordinates", w.Name)
+		}
+	}
+	for _, p := range s.Idle {
+		if p.IsSolar() {
+			return fmt.Errorf("idle %s: sunrise and sunset are only supported in windows", p.Name)
+		}
+	}
+	return nil
+}
+
+// solarWindows returns windows with their sun-relative times resolved for the occurrence
+// starting on day. Windows whose sun event does not happen that day (polar night or
+// midnight sun) are left out.
+func (s *Settings) solarWindows(windows []Window, day time.Time) []Window {
+	solarUsed := false
+	for _, w := range windows {
+		solarUsed = solarUsed || w.IsSolar()
+	}
+	if !solarUsed || s.Coordinates == nil {
+		return windows
+	}
+
+	resolved := make([]Window, 0, len(windows))
+	for _, w := range windows {
+		if !w.IsSolar() {
+			resolved = append(resolved, w)
+			continue
+		}
+		start, ok1 := s.solarTime(w.Start, day)
+		end, ok2 := s.solarTime(w.End, day)
+		// 跨越午夜的窗口，结束时间按第二天的日出日落计算
+		if ok1 && ok2 && CrossesMidnight(start.Hour, start.Minute, end.Hour, end.Minute) {
+			end, ok2 = s.solarTime(w.End, day.AddDate(0, 0, 1))
+		}
+		if !ok1 || !ok2 {
+			applog.Debugf("窗口 %s 在 %s 没有日出或日落，跳过", w.Name, day.Format("2006-01-02"))
+			continue
+		}
+		w.Start, w.End = start, end
+		resolved = append(resolved, w)
+	}
+	return resolved
+}
+
+// solarTime resolves a sun-relative time for day, in day's time zone
+func (s *Settings) solarTime(t TimeOfDay, day time.Time) (TimeOfDay, bool) {
+	if t.Solar == "" {
+		return t, true
+	}
+	y, m, d := day.Date()
+	sunrise, sunset, ok := solar.Times(y, m, d, s.Coordinates.Latitude, s.Coordinates.Longitude)
+	if !ok {
+		return t, false
+	}
+	at := sunrise
+	if t.Solar == Sunset {
+		at = sunset
+	}
+	at = at.Add(t.Offset).Round(time.Minute).In(day.Location())
+	t.Hour, t.Minute = at.Hour(), at.Minute()
+	return t, true
+}
+
+// WindowsOn returns the windows that start on day with sunrise and sunset times resolved
+func (s *Settings) WindowsOn(day time.Time) []Window {
+	y, m, d := day.Date()
+	windows, _ := s.windowsForDay(time.Date(y, m, d, 0, 0, 0, 0, day.Location()))
+	return windows
+}
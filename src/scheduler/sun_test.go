@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeOfDay(t *testing.T) {
+	tests := []struct {
+		value string
+		want  TimeOfDay
+	}{
+		{"07:30", TimeOfDay{Hour: 7, Minute: 30}},
+		{"sunset", TimeOfDay{Solar: Sunset}},
+		{" Sunrise ", TimeOfDay{Solar: Sunrise}},
+		{"sunset+30m", TimeOfDay{Solar: Sunset, Offset: 30 * time.Minute}},
+		{"sunrise-1h15m", TimeOfDay{Solar: Sunrise, Offset: -75 * time.Minute}},
+		{"SUNSET+2h", TimeOfDay{Solar: Sunset, Offset: 2 * time.Hour}},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeOfDay(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTimeOfDay(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "sunset30m", "sunset+", "sunset+x", "sunset 30m", "sundown", "noon", "24:00"} {
+		if got, err := ParseTimeOfDay(value); err == nil {
+			t.Errorf("ParseTimeOfDay(%q) = %+v, want an error", value, got)
+		}
+	}
+
+	// String 的结果可以重新解析
+	for _, value := range []string{"sunset+30m", "sunrise-75m", "sunset", "07:30"} {
+		parsed, _ := ParseTimeOfDay(value)
+		if again, err := ParseTimeOfDay(parsed.String()); err != nil || again != parsed {
+			t.Errorf("ParseTimeOfDay(%q) = %+v, %v, want %+v", parsed.String(), again, err, parsed)
+		}
+	}
+}
+
+func TestSolarWindows(t *testing.T) {
+	start, _ := ParseTimeOfDay("sunset+30m")
+	end, _ := ParseTimeOfDay("sunrise-1h")
+	windows := []Window{{Name: "evening", Start: start, End: end, Mode: "shutdown"}}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	s := Settings{Coordinates: &Coordinates{Latitude: 52.52, Longitude: 13.405}}
+	// 夏至日落 21:33，次日日出 04:43（夏令时）
+	got := s.solarWindows(windows, time.Date(2026, 6, 21, 0, 0, 0, 0, berlin))
+	if len(got) != 1 || got[0].Start.Clock() != "22:03" || got[0].End.Clock() != "03:43" {
+		t.Fatalf("solarWindows() in Berlin = %+v, want 22:03-03:43", got)
+	}
+	if got[0].Start.Solar != Sunset || got[0].Start.String() != "sunset+30m" {
+		t.Errorf("resolved start = %v, want it to stay sunset+30m", got[0].Start)
+	}
+
+	// 极夜时没有日落，窗口当天不生效
+	s.Coordinates = &Coordinates{Latitude: 69.65, Longitude: 18.96}
+	if got := s.solarWindows(windows, time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC)); len(got) != 0 {
+		t.Errorf("solarWindows() in the polar night = %+v, want none", got)
+	}
+}
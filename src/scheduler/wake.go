@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+)
+
+// WakeTime wakes the machine at a time of day on the given days, e.g. for morning tasks
+type WakeTime struct {
+	Days Weekdays  `json:"days,omitempty"`
+	At   TimeOfDay `json:"at"`
+}
+
+func (w WakeTime) String() string {
+	return fmt.Sprintf("%s %s", w.Days, w.At)
+}
+
+// validateWake checks the wake times
+func (s *Settings) validateWake() error {
+	for i, w := range s.Wake {
+		if w.At.Solar != "" {
+			return fmt.Errorf("wake %d: sunrise and sunset times are not supported", i+1)
+		}
+	}
+	return nil
+}
+
+// NextWake returns the first wake time after now, or the zero time if none is configured
+func (s *Settings) NextWake(now time.Time) time.Time {
+	loc := s.Location()
+	now = now.In(loc)
+	y, m, d := now.Date()
+
+	var next time.Time
+	for i := 0; i <= 7 && next.IsZero(); i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, loc)
+		for _, w := range s.Wake {
+			if !w.Days.Contains(day.Weekday()) {
+				continue
+			}
+			t := resolveWall(day.Year(), day.Month(), day.Day(), w.At.Hour, w.At.Minute, loc, false)
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// SetWake wakes the machine at at on days, replacing the wake times of those days
+func (s *Settings) SetWake(days Weekdays, at TimeOfDay) {
+	s.ClearWake(days)
+	s.Wake = append(s.Wake, WakeTime{Days: days, At: at})
+}
+
+// ClearWake removes the wake times of days; no days means all of them
+func (s *Settings) ClearWake(days Weekdays) {
+	var kept []WakeTime
+	for _, w := range s.Wake {
+		var left Weekdays
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if w.Days.Contains(d) && !days.Contains(d) {
+				left = append(left, d)
+			}
+		}
+		if len(left) == 0 {
+			continue
+		}
+		if len(left) < 7 {
+			w.Days = left
+		}
+		kept = append(kept, w)
+	}
+	s.Wake = kept
+}
+
+// tickWake keeps the wake timer armed for the next wake time, so it is in place
+// whenever the machine goes to sleep or is powered off
+func (s *Scheduler) tickWake(now time.Time, cfg Settings) {
+	if s.Wake == nil {
+		return
+	}
+	next := cfg.NextWake(now)
+	// 唤醒时间过后设置下一个
+	s.plan(now, next)
+	if next.Equal(s.wakeArmed) {
+		return
+	}
+	// 失败时同样记录，避免每次检查都重试并写入日志
+	s.wakeArmed = next
+
+	if next.IsZero() {
+		if err := s.Wake.Clear(); err != nil {
+			log.Printf(i18n.T("log_wake_failed", err))
+			return
+		}
+		log.Printf(i18n.T("log_wake_cleared"))
+		return
+	}
+	if err := s.Wake.Set(next); err != nil {
+		log.Printf(i18n.T("log_wake_failed", err))
+		return
+	}
+	log.Printf(i18n.T("log_wake_armed", next.Format("2006-01-02 15:04 MST")))
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWake(t *testing.T) {
+	weekdays, err := ParseWeekdays("weekdays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hour, minute int) TimeOfDay { return TimeOfDay{Hour: hour, Minute: minute} }
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 时区为 America/New_York：2026-03-08 跳过 02:00-03:00，2026-11-01 重复 01:00-02:00
+	tests := []struct {
+		name string
+		wake []WakeTime
+		now  time.Time
+		want time.Time
+	}{
+		{"none", nil, utc(time.October, 19, 12, 0), time.Time{}},
+		{"later today", []WakeTime{{At: at(6, 30)}}, utc(time.October, 19, 9, 0), utc(time.October, 19, 10, 30)},
+		{"passed today", []WakeTime{{At: at(6, 30)}}, utc(time.October, 19, 11, 0), utc(time.October, 20, 10, 30)},
+		{"exactly now", []WakeTime{{At: at(6, 30)}}, utc(time.October, 19, 10, 30), utc(time.October, 20, 10, 30)},
+		{"weekend skipped", []WakeTime{{Days: weekdays, At: at(6, 30)}}, utc(time.October, 24, 14, 0), utc(time.October, 26, 10, 30)},
+		{"earliest entry", []WakeTime{{At: at(7, 0)}, {Days: Weekdays{time.Tuesday}, At: at(5, 45)}}, utc(time.October, 19, 12, 0), utc(time.October, 20, 9, 45)},
+		{"in skipped hour", []WakeTime{{At: at(2, 30)}}, utc(time.March, 8, 5, 0), utc(time.March, 8, 7, 0)},
+		{"after spring forward", []WakeTime{{At: at(6, 30)}}, utc(time.March, 8, 5, 0), utc(time.March, 8, 10, 30)},
+		{"in repeated hour", []WakeTime{{At: at(1, 30)}}, utc(time.November, 1, 4, 0), utc(time.November, 1, 5, 30)},
+		{"not repeated", []WakeTime{{At: at(1, 30)}}, utc(time.November, 1, 5, 45), utc(time.November, 2, 6, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Settings{Mode: "shutdown", TimeZone: "America/New_York", Wake: tt.wake}
+			if err := s.Validate(); err != nil {
+				t.Skipf("time zone data not available: %v", err)
+			}
+			got := s.NextWake(tt.now)
+			if !got.Equal(tt.want) {
+				t.Errorf("NextWake(%v) = %v, want %v", tt.now.In(s.Location()), got, tt.want.In(s.Location()))
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sun events a TimeOfDay can be anchored to
+const (
+	Sunrise = "sunrise"
+	Sunset  = "sunset"
+)
+
+// TimeOfDay is a wall clock time without a date. A time anchored to the sun
+// (e.g. "sunset+30m") has Solar and Offset set; Hour and Minute are then
+// filled in for each day by Settings.windowsForDay.
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+	Solar  string
+	Offset time.Duration
+}
+
+func (t TimeOfDay) String() string {
+	if t.Solar == "" {
+		return t.Clock()
+	}
+	if t.Offset == 0 {
+		return t.Solar
+	}
+	return fmt.Sprintf("%s%+dm", t.Solar, int(t.Offset/time.Minute))
+}
+
+// Clock returns the time as HH:MM, for solar times the value resolved for the current day
+func (t TimeOfDay) Clock() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// ParseTimeOfDay parses "HH:MM" or a sun-relative time like "sunset", "sunset+30m" or "sunrise-1h15m"
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, event := range []string{Sunrise, Sunset} {
+		if !strings.HasPrefix(value, event) {
+			continue
+		}
+		t := TimeOfDay{Solar: event}
+		if rest := value[len(event):]; rest != "" {
+			offset, err := time.ParseDuration(rest)
+			if err != nil || (rest[0] != '+' && rest[0] != '-') {
+				return TimeOfDay{}, fmt.Errorf("invalid time %q, expected e.g. %s+30m", value, event)
+			}
+			t.Offset = offset
+		}
+		return t, nil
+	}
+
+	hour, minute, ok := ParseClock(value)
+	if !ok {
+		return TimeOfDay{}, fmt.Errorf("invalid time %q, expected HH:MM, sunrise or sunset", value)
+	}
+	return TimeOfDay{Hour: hour, Minute: minute}, nil
+}
+
+// minutes returns the number of minutes since midnight
+func (t TimeOfDay) minutes() int {
+	return t.Hour*60 + t.Minute
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseTimeOfDay(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Weekdays is a set of days of the week; the empty set means every day
+type Weekdays []time.Weekday
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWeekdays parses a comma separated list of days and ranges, e.g. "mon-thu,sun".
+// The shorthands "daily", "weekdays" and "weekends" are also accepted.
+func ParseWeekdays(value string) (Weekdays, error) {
+	var days Weekdays
+	for _, part := range strings.Split(strings.ToLower(value), ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "", "daily", "all":
+			return nil, nil
+		case "weekdays":
+			days = append(days, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+			continue
+		case "weekends":
+			days = append(days, time.Saturday, time.Sunday)
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		first, ok := parseWeekday(bounds[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = parseWeekday(bounds[1]); !ok {
+				return nil, fmt.Errorf("invalid weekday %q", bounds[1])
+			}
+		}
+		// 支持跨周末的范围，如 fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.TrimSpace(name)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if name == weekdayNames[d] || name == strings.ToLower(d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Contains reports whether d is in the set
+func (w Weekdays) Contains(d time.Weekday) bool {
+	if len(w) == 0 {
+		return true
+	}
+	for _, day := range w {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Weekdays) String() string {
+	if len(w) == 0 {
+		return "daily"
+	}
+	names := make([]string, 0, len(w))
+	for _, d := range w {
+		names = append(names, weekdayNames[d])
+	}
+	return strings.Join(names, ",")
+}
+
+func (w Weekdays) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.String())
+}
+
+func (w *Weekdays) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	days, err := ParseWeekdays(value)
+	if err != nil {
+		return err
+	}
+	*w = days
+	return nil
+}
+
+// Window is a named time range in which the operation is enforced.
+// Days are the days on which the window starts; a window that crosses
+// midnight (e.g. 22:00-06:00) still belongs to the day it started on.
+type Window struct {
+	Name  string    `json:"name"`
+	Days  Weekdays  `json:"days,omitempty"`
+	Start TimeOfDay `json:"start"`
+	End   TimeOfDay `json:"end"`
+	Mode  string    `json:"mode,omitempty"` // Empty means the global operation mode
+	// Minutes after which a shutdown or reboot held back by an application is forced, 0 never forces
+	ForceAfterMinutes int `json:"force_after_minutes,omitempty"`
+}
+
+// CrossesMidnight reports whether the window spans two days (e.g. 22:00-06:00)
+func (w Window) CrossesMidnight() bool {
+	return CrossesMidnight(w.Start.Hour, w.Start.Minute, w.End.Hour, w.End.Minute)
+}
+
+// Occurrence returns the start and end of the occurrence of w that begins on day,
+// in day's time zone. See resolveWall for how DST changes are handled.
+func (w Window) Occurrence(day time.Time) (time.Time, time.Time) {
+	y, m, d := day.Date()
+	start := resolveWall(y, m, d, w.Start.Hour, w.Start.Minute, day.Location(), false)
+	if w.CrossesMidnight() {
+		d++
+	}
+	end := resolveWall(y, m, d, w.End.Hour, w.End.Minute, day.Location(), true)
+	return start, end
+}
+
+// candidateDays returns the days whose window occurrences may contain t:
+// today and, for windows that cross midnight, yesterday
+func candidateDays(t time.Time) []time.Time {
+	y, m, d := t.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return []time.Time{today, time.Date(y, m, d-1, 0, 0, 0, 0, t.Location())}
+}
+
+func occurrenceContains(w Window, day, t time.Time) bool {
+	start, end := w.Occurrence(day)
+	return !t.Before(start) && t.Before(end)
+}
+
+// ParseRange parses a "HH:MM-HH:MM" range
+func ParseRange(value string) (TimeOfDay, TimeOfDay, error) {
+	bounds := strings.Split(strings.TrimSpace(value), "-")
+	if len(bounds) == 2 {
+		h1, m1, ok1 := ParseClock(strings.TrimSpace(bounds[0]))
+		h2, m2, ok2 := ParseClock(strings.TrimSpace(bounds[1]))
+		if ok1 && ok2 {
+			return TimeOfDay{Hour: h1, Minute: m1}, TimeOfDay{Hour: h2, Minute: m2}, nil
+		}
+	}
+	return TimeOfDay{}, TimeOfDay{}, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", value)
+}
+
+// ModeOr returns the window's operation mode, or fallback if it has none
+func (w Window) ModeOr(fallback string) string {
+	if w.Mode != "" {
+		return w.Mode
+	}
+	return fallback
+}
+
+// ForceAfter returns how long a graceful shutdown or reboot may be held back before it is forced
+func (w Window) ForceAfter() time.Duration {
+	return time.Duration(w.ForceAfterMinutes) * time.Minute
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%s %s %s-%s", w.Name, w.Days, w.Start, w.End)
+}
+
+// IsSolar reports whether the window starts or ends relative to sunrise or sunset
+func (w Window) IsSolar() bool {
+	return w.Start.Solar != "" || w.End.Solar != ""
+}
+
+// CrossesMidnight reports whether the range start-end spans two days (e.g. 22:00-06:00)
+func CrossesMidnight(startHour, startMinute, endHour, endMinute int) bool {
+	return !(startHour < endHour || (startHour == endHour && startMinute <= endMinute))
+}
+
+// ParseClock parses a HH:MM string into hour and minute
+func ParseClock(value string) (int, int, bool) {
+	timeParts := strings.Split(value, ":")
+	if len(timeParts) != 2 {
+		return 0, 0, false
+	}
+
+	hour, err1 := strconv.Atoi(timeParts[0])
+	minute, err2 := strconv.Atoi(timeParts[1])
+
+	if err1 != nil || err2 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
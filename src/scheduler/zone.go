@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// Location returns the time zone the windows are evaluated in, the system zone if none is configured
+func (s Settings) Location() *time.Location {
+	if s.location == nil {
+		return time.Local
+	}
+	return s.location
+}
+
+// validateTimeZone loads the configured IANA time zone
+func (s *Settings) validateTimeZone() error {
+	s.location = nil
+	if s.TimeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return err
+	}
+	s.location = loc
+	return nil
+}
+
+// resolveWall returns the instant at which the clock in loc shows the given date and time.
+// DST changes make this ambiguous, so the rules are:
+//   - a time skipped when the clock springs forward resolves to the moment of the change
+//   - a time that occurs twice when the clock falls back resolves to its first
+//     occurrence, or to its second one if last is set
+//
+// Window starts use the first and window ends the last occurrence, so a window
+// covers every instant whose clock reading lies inside it.
+func resolveWall(year int, month time.Month, day, hour, minute int, loc *time.Location, last bool) time.Time {
+	// 把墙上时间当作 UTC 时刻，再减去转换前后两个偏移量得到候选时刻
+	naive := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	_, before := naive.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := naive.Add(24 * time.Hour).In(loc).Zone()
+
+	var valid []time.Time
+	for _, offset := range []int{before, after} {
+		c := naive.Add(-time.Duration(offset) * time.Second).In(loc)
+		if sameWall(c, naive) {
+			valid = append(valid, c)
+		}
+	}
+
+	switch {
+	case len(valid) == 2 && !valid[0].Equal(valid[1]):
+		first, second := valid[0], valid[1]
+		if second.Before(first) {
+			first, second = second, first
+		}
+		if last {
+			return second
+		}
+		return first
+	case len(valid) > 0:
+		return valid[0]
+	}
+
+	// 时间被跳过：在两个候选时刻之间二分查找偏移量变化的时刻
+	lo := naive.Add(-time.Duration(after) * time.Second)
+	hi := naive.Add(-time.Duration(before) * time.Second)
+	if hi.Before(lo) {
+		lo, hi = hi, lo
+	}
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2)
+		if _, offset := mid.In(loc).Zone(); offset == before {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi.In(loc)
+}
+
+// NextClock returns the next instant after now at which the clock in now's zone shows
+// hour:minute. The repeated hour after a fall back is tried in both occurrences, and
+// a time skipped by a spring forward resolves to the moment of the change.
+func NextClock(now time.Time, hour, minute int) time.Time {
+	loc := now.Location()
+	y, m, d := now.Date()
+	for _, last := range []bool{false, true} {
+		if at := resolveWall(y, m, d, hour, minute, loc, last); at.After(now) {
+			return at
+		}
+	}
+	return resolveWall(y, m, d+1, hour, minute, loc, false)
+}
+
+// sameWall reports whether t shows the same date and clock time as the naive UTC time
+func sameWall(t, naive time.Time) bool {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := naive.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 && t.Hour() == naive.Hour() && t.Minute() == naive.Minute()
+}
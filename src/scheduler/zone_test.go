@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveWall(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 2026-03-08 02:00 EST 跳到 03:00 EDT，2026-11-01 02:00 EDT 回到 01:00 EST
+	tests := []struct {
+		name         string
+		month        time.Month
+		day          int
+		hour, minute int
+		last         bool
+		want         time.Time
+	}{
+		{"ordinary start", time.March, 7, 22, 0, false, utc(time.March, 8, 3, 0)},
+		{"ordinary end", time.March, 7, 22, 0, true, utc(time.March, 8, 3, 0)},
+		{"start before spring forward", time.March, 8, 1, 59, false, utc(time.March, 8, 6, 59)},
+		{"start in skipped hour", time.March, 8, 2, 30, false, utc(time.March, 8, 7, 0)},
+		{"end in skipped hour", time.March, 8, 2, 30, true, utc(time.March, 8, 7, 0)},
+		{"start at skipped hour", time.March, 8, 2, 0, false, utc(time.March, 8, 7, 0)},
+		{"start after spring forward", time.March, 8, 3, 0, false, utc(time.March, 8, 7, 0)},
+		{"start in repeated hour", time.November, 1, 1, 30, false, utc(time.November, 1, 5, 30)},
+		{"end in repeated hour", time.November, 1, 1, 30, true, utc(time.November, 1, 6, 30)},
+		{"start at repeated hour", time.November, 1, 1, 0, false, utc(time.November, 1, 5, 0)},
+		{"end at repeated hour", time.November, 1, 1, 0, true, utc(time.November, 1, 6, 0)},
+		{"after fall back", time.November, 1, 2, 0, false, utc(time.November, 1, 7, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveWall(2026, tt.month, tt.day, tt.hour, tt.minute, loc, tt.last)
+			if !got.Equal(tt.want) {
+				t.Errorf("resolveWall(%02d:%02d, last=%v) = %v, want %v", tt.hour, tt.minute, tt.last, got.UTC(), tt.want)
+			}
+			if got.Location() != loc {
+				t.Errorf("resolveWall() returned a time in %v, want %v", got.Location(), loc)
+			}
+		})
+	}
+}
+
+func TestNextClock(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		hour, minute int
+		want         time.Time
+	}{
+		{"later today", utc(time.March, 7, 17, 0), 22, 0, utc(time.March, 8, 3, 0)},
+		{"passed today", utc(time.March, 7, 17, 0), 8, 0, utc(time.March, 8, 12, 0)},
+		{"exactly now", utc(time.March, 7, 17, 0), 12, 0, utc(time.March, 8, 16, 0)},
+		{"skipped hour", utc(time.March, 8, 5, 0), 2, 30, utc(time.March, 8, 7, 0)},
+		{"first of repeated hour", utc(time.November, 1, 4, 0), 1, 30, utc(time.November, 1, 5, 30)},
+		{"second of repeated hour", utc(time.November, 1, 5, 45), 1, 30, utc(time.November, 1, 6, 30)},
+		{"after repeated hour", utc(time.November, 1, 6, 45), 1, 30, utc(time.November, 2, 6, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.now.In(loc)
+			got := NextClock(now, tt.hour, tt.minute)
+			if !got.Equal(tt.want) {
+				t.Errorf("NextClock(%v, %02d:%02d) = %v, want %v", now, tt.hour, tt.minute, got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveWindowAcrossDST(t *testing.T) {
+	s := Settings{
+		Mode:     "shutdown",
+		TimeZone: "America/New_York",
+		Windows: []Window{
+			{Name: "night", Start: TimeOfDay{Hour: 22}, End: TimeOfDay{Hour: 1, Minute: 30}},
+		},
+	}
+	if err := s.Validate(); err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		active bool
+	}{
+		{"before the window", time.Date(2026, 11, 1, 1, 59, 0, 0, time.UTC), false},
+		{"first 01:15", time.Date(2026, 11, 1, 5, 15, 0, 0, time.UTC), true},
+		{"first 01:45", time.Date(2026, 11, 1, 5, 45, 0, 0, time.UTC), true},
+		{"second 01:15", time.Date(2026, 11, 1, 6, 15, 0, 0, time.UTC), true},
+		{"second 01:30", time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC), false},
+		{"spring forward 03:10", time.Date(2026, 3, 8, 7, 10, 0, 0, time.UTC), false},
+		{"spring forward 01:10", time.Date(2026, 3, 8, 6, 10, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if _, ok := s.ActiveWindow(tt.at.In(s.Location())); ok != tt.active {
+			t.Errorf("%s: ActiveWindow(%v) = %v, want %v", tt.name, tt.at.In(s.Location()), ok, tt.active)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package solar calculates sunrise and sunset offline from latitude and longitude.
+// It follows the NOAA sunrise equation and is accurate to about a minute
+// outside the polar regions.
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+// j2000 is the Julian date of 2000-01-01 12:00 UTC
+const j2000 = 2451545.0
+
+// Times returns sunrise and sunset on the given date for a place at lat, lon
+// (degrees, north and east positive). ok is false on days the sun does not
+// rise or does not set (polar night or midnight sun).
+func Times(year int, month time.Month, day int, lat, lon float64) (sunrise, sunset time.Time, ok bool) {
+	noon := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	n := math.Floor(julian(noon) - j2000 + 0.5)
+
+	// 平太阳时
+	meanSolarNoon := n - lon/360
+	m := math.Mod(357.5291+0.98560028*meanSolarNoon, 360)
+	c := 1.9148*sin(m) + 0.0200*sin(2*m) + 0.0003*sin(3*m)
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := j2000 + meanSolarNoon + 0.0053*sin(m) - 0.0069*sin(2*lambda)
+
+	// 太阳赤纬和时角，-0.833° 包含大气折射和太阳半径
+	sinDecl := sin(lambda) * sin(23.4397)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHour := (sin(-0.833) - sin(lat)*sinDecl) / (cos(lat) * cosDecl)
+	if cosHour < -1 || cosHour > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHour) * 180 / math.Pi
+
+	return fromJulian(transit - hourAngle/360), fromJulian(transit + hourAngle/360), true
+}
+
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-2440587.5)*86400)), 0).UTC()
+}
+
+func sin(deg float64) float64 {
+	return math.Sin(deg * math.Pi / 180)
+}
+
+func cos(deg float64) float64 {
+	return math.Cos(deg * math.Pi / 180)
+}
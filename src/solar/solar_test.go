@@ -0,0 +1,58 @@
+package solar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimes(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 对照公开的日出日落表，误差在一分钟以内
+	tests := []struct {
+		name            string
+		lat, lon        float64
+		month           time.Month
+		day             int
+		sunrise, sunset time.Time
+	}{
+		{"Berlin summer", 52.52, 13.405, time.June, 21, utc(time.June, 21, 2, 43), utc(time.June, 21, 19, 33)},
+		{"Berlin winter", 52.52, 13.405, time.December, 21, utc(time.December, 21, 7, 15), utc(time.December, 21, 14, 54)},
+		{"Berlin equinox", 52.52, 13.405, time.March, 20, utc(time.March, 20, 5, 10), utc(time.March, 20, 17, 18)},
+		// 洛杉矶的日落在 UTC 的第二天
+		{"Los Angeles summer", 34.05, -118.24, time.June, 21, utc(time.June, 21, 12, 42), utc(time.June, 22, 3, 7)},
+		{"Los Angeles winter", 34.05, -118.24, time.December, 21, utc(time.December, 21, 14, 55), utc(time.December, 22, 0, 47)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sunrise, sunset, ok := Times(2026, tt.month, tt.day, tt.lat, tt.lon)
+			if !ok {
+				t.Fatal("Times() = false, want sunrise and sunset")
+			}
+			if d := sunrise.Sub(tt.sunrise); d < -time.Minute || d > time.Minute {
+				t.Errorf("sunrise = %v, want %v", sunrise, tt.sunrise)
+			}
+			if d := sunset.Sub(tt.sunset); d < -time.Minute || d > time.Minute {
+				t.Errorf("sunset = %v, want %v", sunset, tt.sunset)
+			}
+		})
+	}
+}
+
+func TestTimesPolar(t *testing.T) {
+	// 特罗姆瑟：夏至极昼，冬至极夜
+	for _, month := range []time.Month{time.June, time.December} {
+		if sunrise, sunset, ok := Times(2026, month, 21, 69.65, 18.96); ok {
+			t.Errorf("Times(%s 21) in Tromsø = %v, %v, want no sunrise or sunset", month, sunrise, sunset)
+		}
+	}
+	// 南半球相反，极昼在十二月
+	if _, _, ok := Times(2026, time.December, 21, -77.85, 166.67); ok {
+		t.Error("Times(December 21) at McMurdo = true, want midnight sun")
+	}
+	if _, _, ok := Times(2026, time.March, 20, 69.65, 18.96); !ok {
+		t.Error("Times(March 20) in Tromsø = false, want sunrise and sunset")
+	}
+}
@@ -0,0 +1,186 @@
+// Package tamper detects changes of the system wall clock by comparing it with
+// the time since boot, which the user cannot set
+package tamper
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultThreshold is the smallest clock jump reported when no threshold is configured.
+// It is well above the corrections NTP makes while the service runs.
+const DefaultThreshold = 2 * time.Minute
+
+// processStart is the fallback reference when the platform uptime clock is unavailable
+var processStart = time.Now()
+
+// Responses to a detected clock change
+const (
+	ResponseEnforce = "enforce" // Keep evaluating the schedule on the trusted timeline
+	ResponseAlert   = "alert"   // Only log and audit the change
+	ResponseOperate = "operate" // Run the operation immediately
+)
+
+// Config is the "tamper" section of the config file
+type Config struct {
+	Response         string `json:"response,omitempty"` // Empty disables detection
+	ThresholdSeconds int    `json:"threshold_seconds,omitempty"`
+}
+
+// Enabled reports whether tamper detection is configured
+func (c Config) Enabled() bool {
+	return c.Response != ""
+}
+
+// Threshold returns the configured threshold or DefaultThreshold
+func (c Config) Threshold() time.Duration {
+	if c.ThresholdSeconds > 0 {
+		return time.Duration(c.ThresholdSeconds) * time.Second
+	}
+	return DefaultThreshold
+}
+
+// Validate checks the response
+func (c Config) Validate() error {
+	switch c.Response {
+	case "", ResponseEnforce, ResponseAlert, ResponseOperate:
+		return nil
+	}
+	return fmt.Errorf("tamper: invalid response %q (enforce, alert, operate)", c.Response)
+}
+
+// Event is one detected clock change
+type Event struct {
+	Trusted time.Time     // Time on the trusted timeline when the change was seen
+	Wall    time.Time     // Wall clock after the change
+	Jump    time.Duration // How far the wall clock moved, negative if it was set back
+}
+
+// String returns the event in the audit log format
+func (e Event) String() string {
+	return fmt.Sprintf("%s clock changed by %s, wall clock now %s",
+		e.Trusted.Format("2006-01-02 15:04:05"), e.Jump, e.Wall.Format("2006-01-02 15:04:05"))
+}
+
+// State is the part of the detector saved across service restarts.
+// BaseWall and BaseUptime are a pair of readings taken at the same moment.
+type State struct {
+	BaseWall   time.Time     `json:"base_wall"`
+	BaseUptime time.Duration `json:"base_uptime"`
+	Offset     time.Duration `json:"offset"` // Trusted time minus wall clock
+}
+
+// maxEvents is how many events are kept for status
+const maxEvents = 10
+
+// Detector compares the wall clock with the uptime clock on every observation
+type Detector struct {
+	// AuditPath is the file every detected change is appended to, empty to skip the audit log
+	AuditPath string
+
+	mu     sync.Mutex
+	state  State
+	events []Event
+}
+
+// NewDetector returns a Detector that appends to the audit log at auditPath
+func NewDetector(auditPath string) *Detector {
+	return &Detector{AuditPath: auditPath}
+}
+
+// Observe records a reading of the wall clock and the uptime clock and reports
+// whether the wall clock moved by more than threshold since the baseline
+func (d *Detector) Observe(wall time.Time, uptime, threshold time.Duration) (Event, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 去掉单调时钟读数，只比较墙上时间
+	wall = wall.Round(0)
+	if d.state.BaseWall.IsZero() {
+		d.state.BaseWall, d.state.BaseUptime = wall, uptime
+		return Event{}, false
+	}
+
+	expected := d.state.BaseWall.Add(uptime - d.state.BaseUptime)
+	jump := wall.Sub(expected)
+	if jump < threshold && jump > -threshold {
+		return Event{}, false
+	}
+
+	d.state.Offset -= jump
+	d.state.BaseWall, d.state.BaseUptime = wall, uptime
+	e := Event{Trusted: wall.Add(d.state.Offset), Wall: wall, Jump: jump}
+	d.events = append(d.events, e)
+	if len(d.events) > maxEvents {
+		d.events = d.events[len(d.events)-maxEvents:]
+	}
+	d.audit(e.String())
+	return e, true
+}
+
+// Trusted returns wall moved onto the trusted timeline
+func (d *Detector) Trusted(wall time.Time) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return wall.Add(d.state.Offset)
+}
+
+// Offset returns the difference between the trusted timeline and the wall clock
+func (d *Detector) Offset() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state.Offset
+}
+
+// Accept makes the current wall clock the trusted time again, e.g. after a legitimate correction
+func (d *Detector) Accept(wall time.Time, uptime time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wall = wall.Round(0)
+	d.audit(fmt.Sprintf("%s clock accepted, offset %s cleared", wall.Format("2006-01-02 15:04:05"), d.state.Offset))
+	d.state = State{BaseWall: wall, BaseUptime: uptime}
+}
+
+// Events returns a copy of the recent events, oldest first
+func (d *Detector) Events() []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := make([]Event, len(d.events))
+	copy(events, d.events)
+	return events
+}
+
+// State returns the state to save
+func (d *Detector) State() State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// Restore continues from a saved state. The baseline is only valid within the
+// same boot, so after a reboot (uptime smaller than saved) the state is dropped
+// and false is returned.
+func (d *Detector) Restore(st State, uptime time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if st.BaseWall.IsZero() || uptime < st.BaseUptime {
+		return false
+	}
+	d.state = st
+	return true
+}
+
+// audit appends a line to the audit log
+func (d *Detector) audit(line string) {
+	if d.AuditPath == "" {
+		return
+	}
+	f, err := os.OpenFile(d.AuditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
@@ -0,0 +1,134 @@
+package tamper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	base := time.Date(2026, 10, 18, 14, 0, 0, 0, time.UTC)
+	boot := time.Hour
+
+	tests := []struct {
+		name   string
+		wall   time.Time
+		uptime time.Duration
+		jump   time.Duration
+		found  bool
+	}{
+		{"no change", base.Add(10 * time.Minute), boot + 10*time.Minute, 0, false},
+		{"drift within tolerance", base.Add(10*time.Minute + 90*time.Second), boot + 10*time.Minute, 0, false},
+		{"drift back within tolerance", base.Add(10*time.Minute - 90*time.Second), boot + 10*time.Minute, 0, false},
+		{"forward jump", base.Add(3 * time.Hour), boot + 10*time.Minute, 2*time.Hour + 50*time.Minute, true},
+		{"backward jump", base.Add(-2 * time.Hour), boot + 10*time.Minute, -2*time.Hour - 10*time.Minute, true},
+		{"at the threshold", base.Add(12 * time.Minute), boot + 10*time.Minute, 2 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector("")
+			// 第一次读数只建立基准
+			if _, found := d.Observe(base, boot, DefaultThreshold); found {
+				t.Fatal("Observe() reported a change on the first reading")
+			}
+			e, found := d.Observe(tt.wall, tt.uptime, DefaultThreshold)
+			if found != tt.found || e.Jump != tt.jump {
+				t.Fatalf("Observe() = %v, %v, want jump %v, %v", e.Jump, found, tt.jump, tt.found)
+			}
+			// 可信时间不受修改影响
+			trusted := base.Add(tt.uptime - boot)
+			if !tt.found {
+				trusted = tt.wall
+			}
+			if got := d.Trusted(tt.wall); !got.Equal(trusted) {
+				t.Errorf("Trusted(%v) = %v, want %v", tt.wall, got, trusted)
+			}
+			if found && !e.Trusted.Equal(trusted) {
+				t.Errorf("Event.Trusted = %v, want %v", e.Trusted, trusted)
+			}
+		})
+	}
+}
+
+func TestObserveRebases(t *testing.T) {
+	base := time.Date(2026, 10, 18, 14, 0, 0, 0, time.UTC)
+	d := NewDetector("")
+	d.Observe(base, time.Hour, DefaultThreshold)
+	d.Observe(base.Add(-time.Hour), time.Hour, DefaultThreshold)
+
+	// 修改后的时间成为新基准，之后正常走时不再报告
+	if e, found := d.Observe(base.Add(-50*time.Minute), time.Hour+10*time.Minute, DefaultThreshold); found {
+		t.Errorf("Observe() after the change = %v, want no change", e)
+	}
+	if got, want := d.Trusted(base.Add(-50*time.Minute)), base.Add(10*time.Minute); !got.Equal(want) {
+		t.Errorf("Trusted() = %v, want %v", got, want)
+	}
+
+	// 改回原来的时间时偏移抵消
+	d.Observe(base.Add(20*time.Minute), time.Hour+20*time.Minute, DefaultThreshold)
+	if off := d.Offset(); off != 0 {
+		t.Errorf("Offset() after setting the clock back = %v, want 0", off)
+	}
+	if n := len(d.Events()); n != 2 {
+		t.Errorf("%d events, want 2", n)
+	}
+
+	// 接受修改后的时间，偏移清零
+	d.Observe(base.Add(-time.Hour), 2*time.Hour, DefaultThreshold)
+	d.Accept(base.Add(-time.Hour), 2*time.Hour)
+	if off := d.Offset(); off != 0 {
+		t.Errorf("Offset() after Accept() = %v, want 0", off)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	base := time.Date(2026, 10, 18, 14, 0, 0, 0, time.UTC)
+	d := NewDetector("")
+	d.Observe(base, time.Hour, DefaultThreshold)
+	d.Observe(base.Add(-time.Hour), time.Hour, DefaultThreshold)
+	saved := d.State()
+
+	// 同一次启动中服务重启，停止期间的修改仍被发现
+	restored := NewDetector("")
+	if !restored.Restore(saved, 2*time.Hour) {
+		t.Fatal("Restore() within the same boot = false, want true")
+	}
+	if got, want := restored.Trusted(base), base.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Trusted() after Restore() = %v, want %v", got, want)
+	}
+	if e, found := restored.Observe(base.Add(2*time.Hour), 2*time.Hour, DefaultThreshold); !found || e.Jump != 2*time.Hour {
+		t.Errorf("Observe() after Restore() = %v, %v, want a jump of 2h", e.Jump, found)
+	}
+
+	// 重启后运行时间小于保存时的值，状态作废
+	rebooted := NewDetector("")
+	if rebooted.Restore(saved, 5*time.Minute) {
+		t.Error("Restore() after a reboot = true, want false")
+	}
+	if off := rebooted.Offset(); off != 0 {
+		t.Errorf("Offset() after a reboot = %v, want 0", off)
+	}
+	if NewDetector("").Restore(State{}, 2*time.Hour) {
+		t.Error("Restore() of an empty state = true, want false")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	base := time.Date(2026, 10, 18, 14, 0, 0, 0, time.UTC)
+	d := NewDetector(path)
+	d.Observe(base, time.Hour, DefaultThreshold)
+	d.Observe(base.Add(time.Hour), time.Hour, DefaultThreshold)
+	d.Accept(base.Add(time.Hour), time.Hour)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "clock changed by 1h0m0s") || !strings.Contains(lines[1], "clock accepted") {
+		t.Errorf("audit log =\n%s", data)
+	}
+}
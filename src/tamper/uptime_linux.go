@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package tamper
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// CLOCK_MONOTONIC stops while the system is suspended, CLOCK_BOOTTIME keeps counting
+const (
+	clockMonotonic = 1
+	clockBoottime  = 7
+)
+
+// Uptime returns the time since boot, including time spent suspended or hibernated
+func Uptime() time.Duration {
+	d, ok := clock(clockBoottime)
+	if !ok {
+		return time.Since(processStart)
+	}
+	return d
+}
+
+// Suspended returns the time the system has spent suspended or hibernated since boot
+func Suspended() time.Duration {
+	boot, ok := clock(clockBoottime)
+	mono, ok2 := clock(clockMonotonic)
+	if !ok || !ok2 || boot < mono {
+		return 0
+	}
+	return boot - mono
+}
+
+func clock(id uintptr) (time.Duration, bool) {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, id, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0, false
+	}
+	return time.Duration(ts.Nano()), true
+}
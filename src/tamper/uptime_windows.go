@@ -0,0 +1,47 @@
+//go:build windows
+// +build windows
+
+package tamper
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetTickCount64             = kernel32.NewProc("GetTickCount64")
+	procQueryUnbiasedInterruptTime = kernel32.NewProc("QueryUnbiasedInterruptTime")
+)
+
+// Uptime returns the time since boot, including time spent in sleep or hibernation
+func Uptime() time.Duration {
+	if procGetTickCount64.Find() != nil {
+		return time.Since(processStart)
+	}
+	low, high, _ := procGetTickCount64.Call()
+	ms := uint64(low)
+	// 32位系统上返回值的高位在 EDX 中
+	if unsafe.Sizeof(low) == 4 {
+		ms |= uint64(high) << 32
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Suspended returns the time the system has spent in sleep or hibernation since boot:
+// the tick count includes it, the unbiased interrupt time does not
+func Suspended() time.Duration {
+	if procGetTickCount64.Find() != nil || procQueryUnbiasedInterruptTime.Find() != nil {
+		return 0
+	}
+	var unbiased uint64 // 100ns
+	if r, _, _ := procQueryUnbiasedInterruptTime.Call(uintptr(unsafe.Pointer(&unbiased))); r == 0 {
+		return 0
+	}
+	awake := time.Duration(unbiased) * 100
+	if up := Uptime(); up > awake {
+		return up - awake
+	}
+	return 0
+}
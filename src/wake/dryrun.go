@@ -0,0 +1,42 @@
+package wake
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"codans.com/autoshut/src/i18n"
+)
+
+// DryRun is a Timer that only remembers the alarm and never touches the hardware
+type DryRun struct {
+	mu    sync.Mutex
+	at    time.Time
+	armed bool
+}
+
+// NewDryRun returns a disarmed dry-run timer
+func NewDryRun() *DryRun {
+	return &DryRun{}
+}
+
+func (d *DryRun) Set(t time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	log.Printf(i18n.T("log_dry_run_wake", t.Format("2006-01-02 15:04:05")))
+	d.at, d.armed = t, true
+	return nil
+}
+
+func (d *DryRun) Clear() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.at, d.armed = time.Time{}, false
+	return nil
+}
+
+func (d *DryRun) Armed() (time.Time, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.at, d.armed, nil
+}
@@ -0,0 +1,69 @@
+//go:build linux
+// +build linux
+
+package wake
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rtcTimer writes the alarm to the RTC's wakealarm file in sysfs, which wakes
+// the machine from suspend, hibernation and, if the firmware supports it, power-off
+type rtcTimer struct {
+	path string
+}
+
+// NewTimer returns the wake timer for this platform
+func NewTimer() Timer {
+	return NewRTC("/")
+}
+
+// NewRTC returns a Timer for /sys/class/rtc/rtc0/wakealarm below root, e.g. a fake tree in tests
+func NewRTC(root string) Timer {
+	return rtcTimer{path: filepath.Join(root, "sys", "class", "rtc", "rtc0", "wakealarm")}
+}
+
+func (r rtcTimer) Set(t time.Time) error {
+	// 已设置的闹钟必须先清除，否则内核拒绝写入新值
+	if err := r.Clear(); err != nil {
+		return err
+	}
+	return r.write(strconv.FormatInt(t.Unix(), 10))
+}
+
+func (r rtcTimer) Clear() error {
+	return r.write("0")
+}
+
+func (r rtcTimer) Armed() (time.Time, bool, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || value == "0" {
+		return time.Time{}, false, nil
+	}
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%s: %v", r.path, err)
+	}
+	return time.Unix(sec, 0), true, nil
+}
+
+func (r rtcTimer) write(value string) error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %v", r.path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package wake
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeRTC creates the sysfs directory of rtc0 below a temporary root
+func fakeRTC(t *testing.T) (root, path string) {
+	t.Helper()
+	root = t.TempDir()
+	dir := filepath.Join(root, "sys", "class", "rtc", "rtc0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root, filepath.Join(dir, "wakealarm")
+}
+
+func TestRTCSetClearsFirst(t *testing.T) {
+	root, path := fakeRTC(t)
+	// 用命名管道按顺序记录每次写入，普通文件只能看到最后一次
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	// 读写方式打开，写入时不会因为等待读者而阻塞
+	pipe, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipe.Close()
+
+	at := time.Date(2026, 10, 20, 6, 30, 0, 0, time.UTC)
+	if err := NewRTC(root).Set(at); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := pipe.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(buf[:n]), "0\n1792477800\n"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+}
+
+func TestRTCFile(t *testing.T) {
+	root, path := fakeRTC(t)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rtc := NewRTC(root)
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if _, ok, err := rtc.Armed(); ok || err != nil {
+		t.Errorf("Armed() on an empty file = %v, %v", ok, err)
+	}
+
+	at := time.Date(2026, 10, 20, 6, 30, 0, 0, time.UTC)
+	if err := rtc.Set(at); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "1792477800\n" {
+		t.Errorf("after Set the file holds %q", got)
+	}
+	if got, ok, err := rtc.Armed(); !ok || err != nil || !got.Equal(at) {
+		t.Errorf("Armed() = %v, %v, %v, want %v", got, ok, err, at)
+	}
+
+	if err := rtc.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "0\n" {
+		t.Errorf("after Clear the file holds %q", got)
+	}
+	if _, ok, err := rtc.Armed(); ok || err != nil {
+		t.Errorf("Armed() after Clear = %v, %v", ok, err)
+	}
+
+	if err := os.WriteFile(path, []byte("soon\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := rtc.Armed(); ok || err == nil {
+		t.Errorf("Armed() on garbage = %v, %v, want an error", ok, err)
+	}
+}
+
+func TestRTCMissing(t *testing.T) {
+	rtc := NewRTC(t.TempDir())
+	if err := rtc.Set(time.Now()); err == nil {
+		t.Error("Set() without an RTC succeeded")
+	}
+	if _, _, err := rtc.Armed(); err == nil {
+		t.Error("Armed() without an RTC succeeded")
+	}
+}
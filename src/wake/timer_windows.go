@@ -0,0 +1,81 @@
+//go:build windows
+// +build windows
+
+package wake
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procCreateWaitableTimerW = kernel32.NewProc("CreateWaitableTimerW")
+	procSetWaitableTimer     = kernel32.NewProc("SetWaitableTimer")
+	procCancelWaitableTimer  = kernel32.NewProc("CancelWaitableTimer")
+)
+
+// fileTimeEpoch is the offset between 1601-01-01 (FILETIME) and the Unix epoch in 100ns units
+const fileTimeEpoch = 116444736000000000
+
+// waitableTimer uses a waitable timer with resume enabled. The timer belongs to
+// the process, so it wakes the machine from sleep and hibernation while the
+// service runs, but not from power-off; "Allow wake timers" must be enabled
+// in the power options.
+type waitableTimer struct {
+	mu     sync.Mutex
+	handle syscall.Handle
+	armed  time.Time
+}
+
+// NewTimer returns the wake timer for this platform
+func NewTimer() Timer {
+	return &waitableTimer{}
+}
+
+func (w *waitableTimer) Set(t time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.handle == 0 {
+		// 手动重置的匿名计时器
+		h, _, err := procCreateWaitableTimerW.Call(0, 1, 0)
+		if h == 0 {
+			return fmt.Errorf("CreateWaitableTimer: %v", err)
+		}
+		w.handle = syscall.Handle(h)
+	}
+
+	// 正数表示绝对时间（UTC FILETIME）
+	due := t.UnixNano()/100 + fileTimeEpoch
+	ret, _, err := procSetWaitableTimer.Call(uintptr(w.handle), uintptr(unsafe.Pointer(&due)), 0, 0, 0, 1)
+	if ret == 0 {
+		return fmt.Errorf("SetWaitableTimer: %v", err)
+	}
+	w.armed = t
+	return nil
+}
+
+func (w *waitableTimer) Clear() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.armed = time.Time{}
+	if w.handle == 0 {
+		return nil
+	}
+	ret, _, err := procCancelWaitableTimer.Call(uintptr(w.handle))
+	if ret == 0 {
+		return fmt.Errorf("CancelWaitableTimer: %v", err)
+	}
+	return nil
+}
+
+func (w *waitableTimer) Armed() (time.Time, bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.armed, !w.armed.IsZero(), nil
+}
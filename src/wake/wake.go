@@ -0,0 +1,14 @@
+// Package wake arms the hardware timer that wakes the machine from sleep, hibernation or power-off
+package wake
+
+import "time"
+
+// Timer arms the wake-up of the machine
+type Timer interface {
+	// Set arms the timer to wake the machine at t, replacing an earlier alarm
+	Set(t time.Time) error
+	// Clear disarms the timer
+	Clear() error
+	// Armed returns the time the timer is armed for, or false if it is not armed
+	Armed() (time.Time, bool, error)
+}